@@ -0,0 +1,482 @@
+package main
+
+// GO_TEMPLATE is the runtime of the Go target, the compiled program draws
+// onto an image like render.Image and writes it as a PNG. It needs nothing
+// but the standard library.
+const GO_TEMPLATE = `// Code generated by logo-compiler. DO NOT EDIT.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	imagecolor "image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Values are numbers (float64), words (string) and lists ([]Value)
+type Value = any
+
+var palette = map[string]imagecolor.RGBA{
+	"black":   {0x00, 0x00, 0x00, 0xff},
+	"white":   {0xff, 0xff, 0xff, 0xff},
+	"red":     {0xff, 0x00, 0x00, 0xff},
+	"green":   {0x00, 0xff, 0x00, 0xff},
+	"blue":    {0x00, 0x00, 0xff, 0xff},
+	"yellow":  {0xff, 0xff, 0x00, 0xff},
+	"gray":    {0x88, 0x88, 0x88, 0xff},
+	"magenta": {0xff, 0x00, 0xff, 0xff},
+}
+
+var canvas = image.NewRGBA(image.Rect(0, 0, 640, 480))
+
+var paper = "black"
+var ink = "white"
+var head = struct{ x, y, angle float64 }{320, 240, 0}
+var pendown = false
+
+// logoError is an error of the program, the runtime panics with it and run
+// stops the program with it
+type logoError struct {
+	message string
+}
+
+func (e logoError) Error() string {
+	return e.message
+}
+
+func fail(message string) error {
+	return logoError{message}
+}
+
+func clear() {
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(palette[paper]), image.Point{}, draw.Src)
+}
+
+func home() {
+	head.x, head.y = 320, 240
+	clear()
+}
+
+// drawLine draws a one pixel wide line with the Bresenham algorithm
+func drawLine(x1, y1, x2, y2 int) {
+	dx, dy := abs(x2-x1), -abs(y2-y1)
+	sx, sy := 1, 1
+	if x1 > x2 {
+		sx = -1
+	}
+	if y1 > y2 {
+		sy = -1
+	}
+
+	for e := dx + dy; ; {
+		canvas.Set(x1, y1, palette[ink])
+		if x1 == x2 && y1 == y2 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x1 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y1 += sy
+		}
+	}
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+func degToRad(deg float64) float64 { return deg * (math.Pi / 180) }
+func radToDeg(rad float64) float64 { return rad * (180 / math.Pi) }
+
+func forward(step float64) {
+	dx := step * math.Cos(degToRad(head.angle))
+	dy := step * math.Sin(degToRad(head.angle))
+	if pendown {
+		drawLine(int(int32(head.x)), int(int32(head.y)), int(int32(head.x+dx)), int(int32(head.y+dy)))
+	}
+	head.x += dx
+	head.y += dy
+}
+
+func back(step float64) {
+	forward(-step)
+}
+
+func left(value float64) {
+	head.angle = math.Mod(head.angle+value, 360)
+}
+
+func right(value float64) {
+	head.angle = math.Mod(head.angle-value, 360)
+}
+
+func divide(a, b float64) float64 {
+	if b == 0 {
+		panic(fail("division by zero"))
+	}
+	return a / b
+}
+
+func modulo(a, b float64) float64 {
+	return a - b*math.Floor(divide(a, b))
+}
+
+func sqrt(value float64) float64 {
+	if value < 0 {
+		panic(fail("square root of a negative number"))
+	}
+	return math.Sqrt(value)
+}
+
+func round(value float64) float64 { return math.Round(value) }
+
+func boolWord(value bool) Value {
+	if value {
+		return "true"
+	}
+	return "false"
+}
+
+func truth(value Value) bool {
+	switch strings.ToUpper(word(value)) {
+	case "TRUE":
+		return true
+	case "FALSE":
+		return false
+	}
+	panic(fail("expected true or false"))
+}
+
+func toNumber(value Value) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		if v == "" || strings.ContainsAny(v, "iInNxXpP_") {
+			return 0, false
+		}
+		number, err := strconv.ParseFloat(v, 64)
+		return number, err == nil
+	}
+	return 0, false
+}
+
+func num(value Value) float64 {
+	if number, ok := toNumber(value); ok {
+		return number
+	}
+	panic(fail("expected a number"))
+}
+
+func toWord(value Value) (string, bool) {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case string:
+		return v, true
+	}
+	return "", false
+}
+
+func word(value Value) string {
+	if w, ok := toWord(value); ok {
+		return w
+	}
+	panic(fail("expected a word"))
+}
+
+func list(value Value) []Value {
+	if l, ok := value.([]Value); ok {
+		return l
+	}
+	panic(fail("expected a list"))
+}
+
+// items splits a list to its items and a word to its characters
+func items(value Value) []Value {
+	if l, ok := value.([]Value); ok {
+		return l
+	}
+	chars := []Value{}
+	for _, ch := range word(value) {
+		chars = append(chars, string(ch))
+	}
+	return chars
+}
+
+func nonEmpty(value Value) []Value {
+	all := items(value)
+	if len(all) == 0 {
+		panic(fail("empty word or list"))
+	}
+	return all
+}
+
+func rebuild(original Value, all []Value) Value {
+	if _, ok := original.([]Value); ok {
+		return all
+	}
+	var sb strings.Builder
+	for _, ch := range all {
+		sb.WriteString(word(ch))
+	}
+	return sb.String()
+}
+
+func first(value Value) Value { return nonEmpty(value)[0] }
+
+func last(value Value) Value {
+	all := nonEmpty(value)
+	return all[len(all)-1]
+}
+
+func butFirst(value Value) Value { return rebuild(value, nonEmpty(value)[1:]) }
+
+func butLast(value Value) Value {
+	all := nonEmpty(value)
+	return rebuild(value, all[:len(all)-1])
+}
+
+func item(index float64, value Value) Value {
+	all := items(value)
+	i := int(index)
+	if i < 1 || i > len(all) {
+		panic(fail(fmt.Sprintf("ITEM %d is out of range", i)))
+	}
+	return all[i-1]
+}
+
+func length(value Value) float64 { return float64(len(items(value))) }
+
+func fput(value Value, values Value) Value {
+	return append([]Value{value}, list(values)...)
+}
+
+func lput(value Value, values Value) Value {
+	all := list(values)
+	return append(all[:len(all):len(all)], value)
+}
+
+func sentence(a, b Value) Value {
+	result := []Value{}
+	for _, value := range []Value{a, b} {
+		if l, ok := value.([]Value); ok {
+			result = append(result, l...)
+		} else {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func joinWords(a, b Value) Value { return word(a) + word(b) }
+
+func equal(a, b Value) bool {
+	if x, ok := toNumber(a); ok {
+		if y, ok := toNumber(b); ok {
+			return x == y
+		}
+	}
+	if x, ok := toWord(a); ok {
+		y, ok := toWord(b)
+		return ok && strings.EqualFold(x, y)
+	}
+	x, _ := a.([]Value)
+	y, ok := b.([]Value)
+	if !ok || len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if !equal(x[i], y[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Templates are functions taking their inputs, a missing input is nil
+func slot(q []Value, index int) Value {
+	if index < len(q) {
+		return q[index]
+	}
+	panic(fail(fmt.Sprintf("template slot %d has no input", index+1)))
+}
+
+func applyList(fn func(...Value) Value, value Value) Value { return fn(list(value)...) }
+
+func mapList(fn func(...Value) Value, value Value) Value {
+	result := []Value{}
+	for _, x := range items(value) {
+		result = append(result, fn(x))
+	}
+	return result
+}
+
+func filterList(fn func(...Value) Value, value Value) Value {
+	result := []Value{}
+	for _, x := range items(value) {
+		if truth(fn(x)) {
+			result = append(result, x)
+		}
+	}
+	return result
+}
+
+func reduceList(fn func(...Value) Value, value Value) Value {
+	all := nonEmpty(value)
+	result := all[len(all)-1]
+	for i := len(all) - 2; i >= 0; i-- {
+		result = fn(all[i], result)
+	}
+	return result
+}
+
+// PRINT, SHOW and TYPE write to the standard output
+func formatValue(value Value) string {
+	if l, ok := value.([]Value); ok {
+		all := make([]string, len(l))
+		for i, x := range l {
+			if _, nested := x.([]Value); nested {
+				all[i] = "[" + formatValue(x) + "]"
+			} else {
+				all[i] = formatValue(x)
+			}
+		}
+		return strings.Join(all, " ")
+	}
+	w, _ := toWord(value)
+	return w
+}
+
+func typeValue(value Value) {
+	fmt.Print(formatValue(value))
+}
+
+func printValue(value Value) {
+	fmt.Println(formatValue(value))
+}
+
+func showValue(value Value) {
+	if _, ok := value.([]Value); ok {
+		fmt.Println("[" + formatValue(value) + "]")
+		return
+	}
+	fmt.Println(formatValue(value))
+}
+
+func color(value Value) string {
+	name := strings.ToLower(word(value))
+	if _, ok := palette[name]; !ok {
+		panic(fail("unrecognized color"))
+	}
+	return name
+}
+
+func penState(value Value) bool {
+	switch strings.ToUpper(word(value)) {
+	case "UP":
+		return false
+	case "DOWN":
+		return true
+	}
+	panic(fail("invalid parameter"))
+}
+
+func count(value float64) float64 {
+	if int(value) <= 0 || int(value) >= 65536 {
+		panic(fail("the count is too small or too large number"))
+	}
+	return math.Trunc(value)
+}
+
+// Mulberry32, the interpreter uses the same generator (logo.Mulberry32)
+var seed = uint32(time.Now().UnixNano())
+
+func rerandom(value float64) {
+	seed = uint32(int64(value))
+}
+
+func nextRandom() uint32 {
+	seed += 0x6d2b79f5
+	t := seed
+	t = (t ^ t>>15) * (t | 1)
+	t ^= t + (t^t>>7)*(t|61)
+	return t ^ t>>14
+}
+
+func random(n float64) float64 {
+	limit := int(n)
+	if limit <= 0 {
+		panic(fail("the limit of random must be positive"))
+	}
+	return float64(uint64(nextRandom()) * uint64(limit) >> 32)
+}
+
+func pick(value Value) Value {
+	all := list(value)
+	if len(all) == 0 {
+		panic(fail("cannot pick from an empty list"))
+	}
+	return all[int(random(float64(len(all))))]
+}
+
+// {{compiled-code}}
+
+func main() {
+	output := flag.String("o", "logo.png", "the PNG file to write")
+	flag.Parse()
+
+	clear()
+	// {{seed}}
+	err := run()
+
+	file, createErr := os.Create(*output)
+	if createErr != nil {
+		fmt.Fprintln(os.Stderr, createErr)
+		os.Exit(1)
+	}
+	defer file.Close()
+	if err := png.Encode(file, canvas); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		file.Close()
+		os.Exit(1)
+	}
+}
+
+// run runs the program, the errors of the program end it; anything else is
+// a bug of the runtime and keeps panicking
+func run() (err error) {
+	defer func() {
+		if failure := recover(); failure != nil {
+			if e, ok := failure.(logoError); ok {
+				err = e
+				return
+			}
+			panic(failure)
+		}
+	}()
+
+	program()
+	return nil
+}
+`
@@ -3,37 +3,79 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"html/template"
 	"io"
 	"os"
+	"regexp"
 	"strings"
 
 	"rs.lab/go-logo/logo"
 )
 
+// TEMPLATE is the HTML page of the js target, an html/template. The page
+// needs a canvas with the id canvas and a pre with the id console, -template
+// gives another page with the same fields.
 const TEMPLATE = `<!DOCTYPE html>
 <html lang="en">
 <head>
     <meta charset="UTF-8">
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Logo</title>
+    <title>{{.Title}}</title>
 	<style>
+	{{- if .Background}}
+	body {
+		background: {{.Background}};
+	}
+	{{- end}}
 	canvas {
 		padding-left: 0;
 		padding-right: 0;
 		margin-left: auto;
 		margin-right: auto;
 		display: block;
-		width: 640px;
+		width: {{.Width}}px;
 	}	
+	#console, #source {
+		margin-left: auto;
+		margin-right: auto;
+		width: {{.Width}}px;
+	}
+	#console {
+		min-height: 1em;
+		max-height: 10em;
+		overflow-y: auto;
+		background: #222;
+		color: #ddd;
+	}
 	</style>
 </head>
 <body>
-    <canvas width="640" height="480" id="canvas"></canvas>
+    <canvas width="{{.Width}}" height="{{.Height}}" id="canvas"></canvas>
+    <pre id="console"></pre>
+    {{- if .Source}}
+    <details id="source">
+        <summary>Source</summary>
+        <pre>{{.Source}}</pre>
+    </details>
+    {{- end}}
     <script>
-        const canvas = document.getElementById('canvas');
-        const ctx = canvas.getContext("2d");
-		// To get clear line without AA
-		ctx.translate(0.5, 0.5);
+{{.Script}}
+    </script>
+</body>
+</html>
+`
+
+// RUNTIME is the script running the compiled program at its end. It finds the
+// canvas, the console panel and the options in its scope: the page defines
+// them from its elements, the module gets them as the inputs of draw.
+const RUNTIME = `        const ctx = canvas.getContext("2d");
+		// The drawing is 640x480 at any size of the canvas, shifted by half
+		// a pixel to get clear lines without AA
+		ctx.setTransform(canvas.width / 640, 0, 0, canvas.height / 480, 0.5, 0.5);
+		ctx.lineWidth = 1 / Math.min(canvas.width / 640, canvas.height / 480);
 
         var paper = 'black';
         var ink = 'white';
@@ -41,9 +83,13 @@ const TEMPLATE = `<!DOCTYPE html>
         var pendown = false;
 
         
+        // The paper fills the whole canvas, in its own pixels
         const clear = () => {
+            ctx.save();
+            ctx.setTransform(1, 0, 0, 1, 0, 0);
             ctx.fillStyle = paper;
             ctx.fillRect(0,0,canvas.width, canvas.height);
+            ctx.restore();
         }
 
         const home = () => {
@@ -61,6 +107,7 @@ const TEMPLATE = `<!DOCTYPE html>
         }
         
         const degToRad = (deg) => deg * (Math.PI / 180);
+        const radToDeg = (rad) => rad * (180 / Math.PI);
 
         const calcOffset = (step) => {
             const dx = step * Math.cos(degToRad(head.angle))
@@ -94,14 +141,303 @@ const TEMPLATE = `<!DOCTYPE html>
             head.angle = (head.angle - value) % 360;
         }
 
+        const divide = (a, b) => {
+            if (b === 0) {
+                throw new Error('division by zero');
+            }
+            return a / b;
+        }
+
+        const modulo = (a, b) => a - b * Math.floor(divide(a, b));
+
+        const sqrt = (value) => {
+            if (value < 0) {
+                throw new Error('square root of a negative number');
+            }
+            return Math.sqrt(value);
+        }
+
+        // Rounds half away from zero like the interpreter
+        const round = (value) => Math.sign(value) * Math.round(Math.abs(value));
+
+        const boolWord = (value) => value ? 'true' : 'false';
+
+        const truth = (value) => {
+            const word = String(value).toUpperCase();
+            if (word !== 'TRUE' && word !== 'FALSE') {
+                throw new Error('expected true or false');
+            }
+            return word === 'TRUE';
+        }
+
+        // Values are numbers, words (strings) and lists (arrays)
+        const num = (value) => {
+            if (typeof value === 'number') {
+                return value;
+            }
+            if (typeof value === 'string' && /^[+-]?(\d+\.?\d*|\.\d+)(e[+-]?\d+)?$/i.test(value)) {
+                return Number(value);
+            }
+            throw new Error('expected a number');
+        }
+
+        const word = (value) => {
+            if (Array.isArray(value)) {
+                throw new Error('expected a word');
+            }
+            return String(value);
+        }
+
+        const list = (value) => {
+            if (!Array.isArray(value)) {
+                throw new Error('expected a list');
+            }
+            return value;
+        }
+
+        const items = (value) => Array.isArray(value) ? value : Array.from(word(value));
 
+        const nonEmpty = (value) => {
+            const all = items(value);
+            if (all.length === 0) {
+                throw new Error('empty word or list');
+            }
+            return all;
+        }
+
+        const rebuild = (original, all) => Array.isArray(original) ? all : all.join('');
+
+        const first = (value) => nonEmpty(value)[0];
+        const last = (value) => nonEmpty(value).at(-1);
+        const butFirst = (value) => rebuild(value, nonEmpty(value).slice(1));
+        const butLast = (value) => rebuild(value, nonEmpty(value).slice(0, -1));
+
+        const item = (index, value) => {
+            const all = items(value);
+            index = Math.trunc(index);
+            if (index < 1 || index > all.length) {
+                throw new Error('ITEM ' + index + ' is out of range');
+            }
+            return all[index - 1];
+        }
+
+        const isNumber = (value) => {
+            try {
+                num(value);
+                return true;
+            } catch (e) {
+                return false;
+            }
+        }
+
+        const equal = (a, b) => {
+            if (isNumber(a) && isNumber(b)) {
+                return num(a) === num(b);
+            }
+            if (!Array.isArray(a)) {
+                return !Array.isArray(b) && word(a).toUpperCase() === word(b).toUpperCase();
+            }
+            return Array.isArray(b) && a.length === b.length && a.every((x, i) => equal(x, b[i]));
+        }
+
+        // Templates are functions taking their inputs, like (...q) => q[0] * 2
+        const applyList = (fn, value) => fn(...list(value));
+        const mapList = (fn, value) => items(value).map((x) => fn(x));
+        const filterList = (fn, value) => items(value).filter((x) => truth(fn(x)));
+
+        const reduceList = (fn, value) => {
+            const all = nonEmpty(value);
+            let result = all.at(-1);
+            for (let i = all.length - 2; i >= 0; i--) {
+                result = fn(all[i], result);
+            }
+            return result;
+        }
+
+        // PRINT, SHOW and TYPE write to the console panel under the canvas
+        const formatValue = (value) => Array.isArray(value)
+            ? value.map((x) => Array.isArray(x) ? '[' + formatValue(x) + ']' : formatValue(x)).join(' ')
+            : String(value);
+
+        const typeValue = (value) => {
+            panel.textContent += formatValue(value);
+        }
+
+        const printValue = (value) => {
+            typeValue(value);
+            typeValue('\n');
+        }
+
+        const showValue = (value) => {
+            typeValue(Array.isArray(value) ? '[' + formatValue(value) + ']' : value);
+            typeValue('\n');
+        }
+
+        // CATCH and THROW, the tags of the running CATCHes, innermost last
+        const catches = [];
+        var failure = [];
+        var line = 0;
+
+        class Thrown {
+            constructor(tag) {
+                this.tag = tag;
+            }
+        }
+
+        // Tags ignore the case
+        const tag = (value) => word(value).toUpperCase();
+
+        const throwTag = (tag) => {
+            if (!catches.includes(tag)) {
+                throw new Error('no CATCH for ' + tag);
+            }
+            throw new Thrown(tag);
+        }
+
+        const caught = (e) => {
+            const tag = catches.at(-1);
+            if (e instanceof Thrown) {
+                return e.tag === tag;
+            }
+            if (tag === 'ERROR') {
+                failure = [e.message, line];
+                return true;
+            }
+            return false;
+        }
+
+        const error = () => {
+            const result = failure;
+            failure = [];
+            return result;
+        }
+
+        const palette = ['black', 'white', 'red', 'green', 'blue', 'yellow', 'gray', 'magenta'];
+
+        const color = (value) => {
+            const name = String(value).toLowerCase();
+            if (!palette.includes(name)) {
+                throw new Error('unrecognized color');
+            }
+            return name;
+        }
+
+        const penState = (value) => {
+            const state = String(value).toUpperCase();
+            if (state !== 'UP' && state !== 'DOWN') {
+                throw new Error('invalid parameter');
+            }
+            return state === 'DOWN';
+        }
+
+        // The fraction of a count is dropped, like the interpreter does
+        const count = (value) => {
+            const n = Math.trunc(value);
+            if (n <= 0 || n >= 65536) {
+                throw new Error('the count is too small or too large number');
+            }
+            return n;
+        }
+
+        // Mulberry32, the interpreter uses the same generator (logo.Mulberry32)
+        var seed = (options.seed ?? Date.now()) >>> 0;
+
+        const rerandom = (value) => {
+            seed = value >>> 0;
+        }
+
+        const nextRandom = () => {
+            seed = (seed + 0x6d2b79f5) >>> 0;
+            let t = seed;
+            t = Math.imul(t ^ (t >>> 15), t | 1);
+            t ^= t + Math.imul(t ^ (t >>> 7), t | 61);
+            return (t ^ (t >>> 14)) >>> 0;
+        }
+
+        const random = (n) => {
+            if (n <= 0) {
+                throw new Error('the limit of random must be positive');
+            }
+            return Math.floor(nextRandom() * n / 4294967296);
+        }
+
+        const pick = (list) => {
+            if (list.length === 0) {
+                throw new Error('cannot pick from an empty list');
+            }
+            return list[random(list.length)];
+        }
+
+
+        // {{seed}}
         // {{compiled-code}}
-    </script>
-</body>
-</html>
 `
 
+// PAGE_RUNTIME is the script of the page
+const PAGE_RUNTIME = `        const canvas = document.getElementById('canvas');
+        const panel = document.getElementById('console');
+        const options = {};
+` + RUNTIME
+
+// MODULE is the runtime of the esm target, an ES module exporting draw. The
+// runtime is inside draw, so the drawings of a page have a state each.
+const MODULE = `// Generated by logo-compiler.
+
+// draw runs the program on the canvas. The options are the seed of RANDOM
+// and PICK and the console, an element the printed text is added to; draw
+// outputs the printed text as well.
+export function draw(canvas, options = {}) {
+        const panel = options.console ?? {textContent: ''};
+` + RUNTIME + `
+        return panel.textContent;
+}
+`
+
+// CSS_COLOR matches the colors -background takes: a name, a hex color or a
+// color function of numbers. Anything else could end the CSS rule.
+var CSS_COLOR = regexp.MustCompile(`^(#[0-9a-fA-F]{3,8}|[a-zA-Z]+|(rgb|rgba|hsl|hsla)\([0-9a-z.,%/ +-]*\))$`)
+
+// Page is what the page template gets
+type Page struct {
+	Title      string
+	Width      int
+	Height     int
+	Background template.CSS // the CSS color of the page, the browser's when empty
+	Source     string       // the Logo source, shown under the canvas when not empty
+	Script     template.JS  // the runtime and the compiled program
+}
+
 func main() {
+	seed := flag.Int64("seed", 0, "seed for RANDOM and PICK, the page picks one when not set")
+	target := flag.String("target", "js", "the target language: js for an HTML5 page, esm for a JavaScript module, go for a Go program drawing a PNG, python for a turtle program")
+	comments := flag.Bool("comments", false, "write the source lines as comments in the code")
+	width := flag.Int("width", 640, "the width of the canvas on the page, the drawing is scaled to it")
+	height := flag.Int("height", 480, "the height of the canvas on the page")
+	title := flag.String("title", "Logo", "the title of the page")
+	background := flag.String("background", "", "the CSS color of the page around the canvas")
+	embed := flag.Bool("source", false, "show the Logo source on the page under the canvas")
+	pageFile := flag.String("template", "", "an html/template `file` for the page instead of the built-in one")
+	flag.Parse()
+
+	if *width <= 0 || *height <= 0 {
+		fail(fmt.Errorf("the canvas of %dx%d is too small", *width, *height))
+	}
+	if *background != "" && !CSS_COLOR.MatchString(*background) {
+		fail(fmt.Errorf("-background %s is not a CSS color", *background))
+	}
+
+	backend, ok := logo.BACKENDS[*target]
+	if !ok {
+		fail(fmt.Errorf("unknown target %s", *target))
+	}
+	// The runtimes of the targets, the markers are comments of the target
+	templates := map[string]struct{ runtime, comment string }{
+		"js":     {PAGE_RUNTIME, "//"},
+		"esm":    {MODULE, "//"},
+		"go":     {GO_TEMPLATE, "//"},
+		"python": {PYTHON_TEMPLATE, "#"},
+	}
+
 	source, err := io.ReadAll(os.Stdin)
 	if err != nil {
 		panic(err)
@@ -111,13 +447,79 @@ func main() {
 	writer := bufio.NewWriter(&buffer)
 
 	c := logo.NewCompiler(writer)
+	c.Backend = backend()
+	c.Comments = *comments
 	// c.Trace = true
 	err = c.Compile(string(source))
 	if err != nil {
-		panic(err)
+		fail(err)
 	}
 	writer.Flush()
 
-	compiled := buffer.String()
-	os.Stdout.WriteString(strings.Replace(TEMPLATE, "// {{compiled-code}}", compiled, -1))
+	seeding := ""
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			seeding = c.Backend.Command("RERANDOM", c.Backend.Number(float64(*seed)))
+			if *target == "esm" { // the seed of the options comes first
+				seeding = c.Backend.Command("RERANDOM", "options.seed ?? "+c.Backend.Number(float64(*seed)))
+			}
+		}
+	})
+
+	runtime := templates[*target]
+	output := strings.Replace(runtime.runtime, runtime.comment+" {{seed}}", seeding, -1)
+	output = strings.Replace(output, runtime.comment+" {{compiled-code}}", buffer.String(), -1)
+	if *target == "js" {
+		page := Page{
+			Title:      *title,
+			Width:      *width,
+			Height:     *height,
+			Background: template.CSS(*background),
+			Script:     template.JS(output),
+		}
+		if *embed {
+			page.Source = string(source)
+		}
+
+		output, err = render(page, *pageFile)
+		if err != nil {
+			fail(err)
+		}
+	}
+	if *target == "go" {
+		formatted, err := format.Source([]byte(output))
+		if err != nil {
+			fail(fmt.Errorf("the compiled Go program does not parse: %w", err))
+		}
+		output = string(formatted)
+	}
+	os.Stdout.WriteString(output)
+}
+
+// render fills the page template, the built-in one when the file is empty
+func render(page Page, file string) (string, error) {
+	text := TEMPLATE
+	if file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		text = string(content)
+	}
+
+	t, err := template.New("page").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	if err := t.Execute(&sb, page); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
 }
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"html/template"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rs.lab/go-logo/logo"
+)
+
+// The canvas, the console panel and the options the runtime finds in its
+// scope, for node
+const NODE_PAGE = `const canvas = {width: 640, height: 480, getContext: () => ({
+    setTransform() {}, save() {}, restore() {}, fillRect() {}, beginPath() {}, moveTo() {}, lineTo() {}, stroke() {},
+})};
+const panel = {textContent: ''};
+const options = {};
+`
+
+// runPage compiles the program to JavaScript and runs the script in node,
+// it returns what the program printed and the error it stopped with
+func runPage(t *testing.T, program string, seed string) string {
+	t.Helper()
+	var code bytes.Buffer
+	writer := bufio.NewWriter(&code)
+	c := logo.NewCompiler(writer)
+	if err := c.Compile(program); err != nil {
+		t.Fatal(err)
+	}
+	writer.Flush()
+
+	seeding := ""
+	if seed != "" {
+		seeding = c.Backend.Command("RERANDOM", seed)
+	}
+	script := strings.Replace(RUNTIME, "// {{seed}}", seeding, 1)
+	script = strings.Replace(script, "// {{compiled-code}}", code.String(), 1)
+	script = NODE_PAGE + "try {\n" + script + "\n} catch (e) {\n    panel.textContent += 'ERROR: ' + e.message;\n}\nprocess.stdout.write(panel.textContent);\n"
+
+	return runNode(t, script)
+}
+
+// runNode runs the script in node and returns what it wrote
+func runNode(t *testing.T, script string) string {
+	t.Helper()
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed")
+	}
+
+	file := filepath.Join(t.TempDir(), "page.js")
+	if err := os.WriteFile(file, []byte(script), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command(node, file).CombinedOutput()
+	if err != nil {
+		t.Fatalf("node: %v\n%s", err, out)
+	}
+	return string(out)
+}
+
+// interpret runs the program in the interpreter like runPage
+func interpret(t *testing.T, program string, seed int64) string {
+	t.Helper()
+	r := logo.NewRuntime()
+	var printed bytes.Buffer
+	r.Writer = &printed
+	r.Random.Seed(seed)
+	if err := r.Run(program); err != nil {
+		printed.WriteString("ERROR: " + err.Error())
+	}
+	return printed.String()
+}
+
+func TestPageMatchesInterpreter(t *testing.T) {
+	tests := map[string]string{
+		"fraction of a count": `repeat 2.5 print "x loop
+repeat 1 + 1.5 print "y loop`,
+		"end of the script": `print "a</script>b
+show [<!-- </SCRIPT>]`,
+		"word is a number": `print (word 1 2) + 1
+print word 3 4`,
+		"catch": `to ratio :a :b
+	catch "error [output :a / :b]
+	output 0
+end
+catch "done [print 1 throw "done print 2]
+catch "a [catch "b [throw "a] print "inner]
+catch "error [print 1 / 0]
+show error
+show error
+print ratio 6 3
+print ratio 1 0`,
+		"templates": `to double :x
+	output :x * 2
+end
+run [print "hello print 1 + 2]
+print map [? * 2] [1 2 3]
+print map "double [1 2 3]
+print map [map [? + 1] ?] [[1 2] [3]]
+print filter [? > 1] [1 2 3]
+print reduce [?1 - ?2] [10 3 2]
+foreach [10 20] [print ?]
+print apply [?1 + ?2] [3 4]`,
+		"stop without output": `to f :x
+	if :x > 3 [output "big]
+	stop
+end
+to g :x
+	if :x > 3 [output "big]
+end
+print f 5
+catch "error [print f 1]
+print first error
+catch "error [print g 1]
+print first error`,
+	}
+
+	for name, program := range tests {
+		t.Run(name, func(t *testing.T) {
+			page := runPage(t, program, "")
+			if want := interpret(t, program, 0); page != want {
+				t.Fatalf("the page printed\n%s\nthe interpreter printed\n%s", page, want)
+			}
+		})
+	}
+}
+
+func TestPageEscapes(t *testing.T) {
+	var code bytes.Buffer
+	writer := bufio.NewWriter(&code)
+	c := logo.NewCompiler(writer)
+	c.Comments = true
+	if err := c.Compile("print \"</script><script>alert"); err != nil {
+		t.Fatal(err)
+	}
+	writer.Flush()
+
+	page, err := render(Page{Title: "Logo", Width: 640, Height: 480, Background: "rgb(10, 20, 30)", Script: template.JS(code.String())}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(page, "</script>"); n != 1 {
+		t.Errorf("%d ends of the script on the page:\n%s", n, page)
+	}
+	if !strings.Contains(page, "background: rgb(10, 20, 30);") {
+		t.Errorf("the background is missing:\n%s", page)
+	}
+}
+
+func TestBackgroundColors(t *testing.T) {
+	colors := map[string]bool{
+		"red":                         true,
+		"#fc0":                        true,
+		"#ffcc0080":                   true,
+		"rgb(10,20,30)":               true,
+		"rgba(10, 20, 30, 0.5)":       true,
+		"hsl(120deg 50% 50% / 0.5)":   true,
+		"#ffcc0":                      true, // the browser drops it, it ends nothing
+		"red; } body { display: none": false,
+		"url(x)":                      false,
+		"rgb(1,2,3)</style>":          false,
+		"":                            false,
+	}
+	for color, valid := range colors {
+		if CSS_COLOR.MatchString(color) != valid {
+			t.Errorf("%q: valid is %v", color, !valid)
+		}
+	}
+}
+
+// TestPaperFillsCanvas clears a canvas twice the size of the drawing, the
+// context only keeps the transform and writes the filled rectangles
+func TestPaperFillsCanvas(t *testing.T) {
+	var code bytes.Buffer
+	writer := bufio.NewWriter(&code)
+	c := logo.NewCompiler(writer)
+	if err := c.Compile("paper red home"); err != nil {
+		t.Fatal(err)
+	}
+	writer.Flush()
+
+	context := `const canvas = {width: 1280, height: 960, getContext: () => ({
+    transforms: [], matrix: [1, 0, 0, 1, 0, 0],
+    setTransform(...matrix) { this.matrix = matrix; },
+    save() { this.transforms.push(this.matrix); },
+    restore() { this.matrix = this.transforms.pop(); },
+    fillRect(x, y, w, h) {
+        const [a, , , d, e, f] = this.matrix;
+        process.stdout.write([a*x + e, d*y + f, a*w, d*h].join(' ') + '\n');
+    },
+    beginPath() {}, moveTo() {}, lineTo() {}, stroke() {},
+})};
+const panel = {textContent: ''};
+const options = {};
+`
+	script := strings.Replace(RUNTIME, "// {{compiled-code}}", code.String(), 1)
+	filled := strings.Fields(strings.TrimSpace(runNode(t, context+script)))
+	if len(filled) == 0 || len(filled)%4 != 0 {
+		t.Fatalf("no rectangle is filled: %q", filled)
+	}
+	for i := 0; i < len(filled); i += 4 {
+		if got := strings.Join(filled[i:i+4], " "); got != "0 0 1280 960" {
+			t.Errorf("the paper fills %s, not the canvas", got)
+		}
+	}
+}
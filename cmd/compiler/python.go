@@ -0,0 +1,377 @@
+package main
+
+// PYTHON_TEMPLATE is the runtime of the Python target, the compiled program
+// draws with the turtle module. The world coordinates of the screen are the
+// canvas of the interpreter, 640x480 with the Y axis growing downward, so the
+// turtle moves and turns like the interpreter's.
+const PYTHON_TEMPLATE = `#!/usr/bin/env python3
+# Generated by logo-compiler.
+
+import decimal
+import math
+import re
+import sys
+import time
+import turtle
+
+screen = turtle.Screen()
+screen.setup(640, 480)
+screen.setworldcoordinates(0, 480, 640, 0)
+screen.tracer(0)
+screen.bgcolor("black")
+
+pen = turtle.Turtle()
+pen.hideturtle()
+pen.pencolor("white")
+pen.penup()
+pen.goto(320, 240)
+
+PALETTE = ["black", "white", "red", "green", "blue", "yellow", "gray", "magenta"]
+
+
+class Thrown(Exception):
+    def __init__(self, tag):
+        super().__init__("no CATCH for " + tag)
+        self.tag = tag
+
+
+catches = []
+failure = []
+line = 0
+
+
+def setLine(number):
+    global line
+    line = number
+
+
+def home():
+    down = pen.isdown()
+    pen.penup()
+    pen.goto(320, 240)
+    if down:
+        pen.pendown()
+    pen.clear()
+
+
+def setPen(down):
+    if down:
+        pen.pendown()
+    else:
+        pen.penup()
+
+
+def degToRad(deg):
+    return deg * (math.pi / 180)
+
+
+def radToDeg(rad):
+    return rad * (180 / math.pi)
+
+
+def divide(a, b):
+    if b == 0:
+        raise Exception("division by zero")
+    return a / b
+
+
+def modulo(a, b):
+    return a - b * math.floor(divide(a, b))
+
+
+def sqrt(value):
+    if value < 0:
+        raise Exception("square root of a negative number")
+    return math.sqrt(value)
+
+
+# round rounds half away from zero like the interpreter, not to even
+def round(value):
+    whole = math.floor(abs(value))
+    if abs(value) - whole >= 0.5:
+        whole += 1
+    return math.copysign(whole, value)
+
+
+def boolWord(value):
+    return "true" if value else "false"
+
+
+def truth(value):
+    text = word(value).upper()
+    if text == "TRUE":
+        return True
+    if text == "FALSE":
+        return False
+    raise Exception("expected true or false")
+
+
+NUMBER = re.compile(r"[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?")
+
+
+def toNumber(value):
+    if isinstance(value, (int, float)):
+        return value
+    if isinstance(value, str) and NUMBER.fullmatch(value):
+        return float(value)
+    return None
+
+
+def num(value):
+    number = toNumber(value)
+    if number is None:
+        raise Exception("expected a number")
+    return number
+
+
+# formatNumber writes the shortest form without an exponent, like the
+# interpreter
+def formatNumber(value):
+    if isinstance(value, int):
+        return str(value)
+    if math.isinf(value) or math.isnan(value):
+        return {"inf": "+Inf", "-inf": "-Inf"}.get(repr(value), "NaN")
+    text = format(decimal.Decimal(repr(value)), "f")
+    if "." in text:
+        text = text.rstrip("0").rstrip(".")
+    return text
+
+
+def toWord(value):
+    if isinstance(value, (int, float)):
+        return formatNumber(value)
+    if isinstance(value, str):
+        return value
+    return None
+
+
+def word(value):
+    text = toWord(value)
+    if text is None:
+        raise Exception("expected a word")
+    return text
+
+
+def list(value):
+    if isinstance(value, type([])):
+        return value
+    raise Exception("expected a list")
+
+
+# items splits a list to its items and a word to its characters
+def items(value):
+    if isinstance(value, type([])):
+        return value
+    return [ch for ch in word(value)]
+
+
+def nonEmpty(value):
+    values = items(value)
+    if len(values) == 0:
+        raise Exception("empty word or list")
+    return values
+
+
+def rebuild(original, values):
+    if isinstance(original, type([])):
+        return values
+    return "".join(word(ch) for ch in values)
+
+
+def first(value):
+    return nonEmpty(value)[0]
+
+
+def last(value):
+    return nonEmpty(value)[-1]
+
+
+def butFirst(value):
+    return rebuild(value, nonEmpty(value)[1:])
+
+
+def butLast(value):
+    return rebuild(value, nonEmpty(value)[:-1])
+
+
+def item(index, value):
+    values = items(value)
+    i = int(index)
+    if i < 1 or i > len(values):
+        raise Exception("ITEM %d is out of range" % i)
+    return values[i - 1]
+
+
+def sentence(a, b):
+    result = []
+    for value in (a, b):
+        if isinstance(value, type([])):
+            result.extend(value)
+        else:
+            result.append(value)
+    return result
+
+
+def joinWords(a, b):
+    return word(a) + word(b)
+
+
+def equal(a, b):
+    x, y = toNumber(a), toNumber(b)
+    if x is not None and y is not None:
+        return x == y
+    x, y = toWord(a), toWord(b)
+    if x is not None:
+        return y is not None and x.lower() == y.lower()
+    if not isinstance(b, type([])) or len(a) != len(b):
+        return False
+    return all(equal(p, q) for p, q in zip(a, b))
+
+
+# Templates are functions taking their inputs, a missing input is None
+def slot(q, index):
+    return q[index] if index < len(q) else None
+
+
+def applyList(fn, value):
+    return fn(*list(value))
+
+
+def mapList(fn, value):
+    return [fn(x) for x in items(value)]
+
+
+def filterList(fn, value):
+    return [x for x in items(value) if truth(fn(x))]
+
+
+def reduceList(fn, value):
+    values = nonEmpty(value)
+    result = values[-1]
+    for x in reversed(values[:-1]):
+        result = fn(x, result)
+    return result
+
+
+# PRINT, SHOW and TYPE write to the standard output
+def formatValue(value):
+    if isinstance(value, type([])):
+        return " ".join("[" + formatValue(x) + "]" if isinstance(x, type([])) else formatValue(x) for x in value)
+    text = toWord(value)
+    return "" if text is None else text
+
+
+def typeValue(value):
+    print(formatValue(value), end="")
+
+
+def printValue(value):
+    print(formatValue(value))
+
+
+def showValue(value):
+    if isinstance(value, type([])):
+        print("[" + formatValue(value) + "]")
+    else:
+        print(formatValue(value))
+
+
+def color(value):
+    name = word(value).lower()
+    if name not in PALETTE:
+        raise Exception("unrecognized color")
+    return name
+
+
+def penState(value):
+    text = word(value).upper()
+    if text == "UP":
+        return False
+    if text == "DOWN":
+        return True
+    raise Exception("invalid parameter")
+
+
+def count(value):
+    if int(value) <= 0 or int(value) >= 65536:
+        raise Exception("the count is too small or too large number")
+    return int(value)
+
+
+def tag(value):
+    return word(value).upper()
+
+
+def throwTag(name):
+    if name not in catches:
+        raise Exception("no CATCH for " + name)
+    raise Thrown(name)
+
+
+# caught tells whether the innermost CATCH stops the exception, the tag
+# ERROR keeps the message and the line for ERROR
+def caught(e):
+    global failure
+    name = catches[-1]
+    if isinstance(e, Thrown):
+        return e.tag == name
+    if name == "ERROR":
+        failure = [str(e), line]
+        return True
+    return False
+
+
+def error():
+    global failure
+    result = failure
+    failure = []
+    return result
+
+
+# Mulberry32, the interpreter uses the same generator (logo.Mulberry32)
+seed = int(time.time() * 1000) & 0xFFFFFFFF
+
+
+def rerandom(value):
+    global seed
+    seed = int(value) & 0xFFFFFFFF
+
+
+def nextRandom():
+    global seed
+    seed = (seed + 0x6D2B79F5) & 0xFFFFFFFF
+    t = seed
+    t = ((t ^ (t >> 15)) * (t | 1)) & 0xFFFFFFFF
+    t ^= (t + ((t ^ (t >> 7)) * (t | 61))) & 0xFFFFFFFF
+    return t ^ (t >> 14)
+
+
+def random(n):
+    limit = int(n)
+    if limit <= 0:
+        raise Exception("the limit of random must be positive")
+    return (nextRandom() * limit) >> 32
+
+
+def pick(value):
+    values = list(value)
+    if len(values) == 0:
+        raise Exception("cannot pick from an empty list")
+    return values[random(len(values))]
+
+
+# {{compiled-code}}
+
+def main():
+    sys.setrecursionlimit(25000)
+    # {{seed}}
+    try:
+        program()
+    except Exception as e:
+        print(e, file=sys.stderr)
+    screen.update()
+    turtle.done()
+
+
+main()
+`
@@ -0,0 +1,17 @@
+package main
+
+import "testing"
+
+func TestSeededRandomMatchesInterpreter(t *testing.T) {
+	program := `
+repeat 20 type random 1000 type "- loop
+print "
+repeat 5 print pick [red green blue yellow magenta] loop
+print random 2097151
+`
+
+	page := runPage(t, program, "5")
+	if want := interpret(t, program, 5); page != want {
+		t.Fatalf("the page printed\n%s\nthe interpreter printed\n%s", page, want)
+	}
+}
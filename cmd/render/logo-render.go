@@ -0,0 +1,132 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"rs.lab/go-logo/logo"
+	"rs.lab/go-logo/render"
+)
+
+func main() {
+	seed := flag.Int64("seed", 0, "seed for RANDOM and PICK, a random one is used when not set")
+	format := flag.String("format", "pdf", "output format: pdf, gcode, hpgl, dxf, tikz, gif, or sixel and kitty for the terminal")
+	output := flag.String("o", "", "output file, the standard output when not set")
+	page := flag.String("page", "a4", "PDF page size: a3, a4, a5, letter, legal or <width>x<height> in mm")
+	scale := flag.Float64("scale", 0.25, "G-code and HPGL millimetres, DXF drawing units, TikZ centimetres (0.025 by default) for a unit of the 640x480 canvas")
+	flip := flag.Bool("flip", true, "G-code, HPGL and DXF Y axis grows upward")
+	feed := flag.Float64("feed", 1500, "G-code speed of the drawing moves in mm/min")
+	penUp := flag.String("pen-up", "M5", "G-code command lifting the pen, like M5 or \"G0 Z5\"")
+	penDown := flag.String("pen-down", "M3", "G-code command lowering the pen, like M3 or \"G1 Z0\"")
+	pens := flag.String("pens", "", "HPGL pens of the inks like red=2,blue=3, black is 1, white 2 and so on by default")
+	every := flag.Int("every", 10, "GIF lines drawn between two frames")
+	delay := flag.Int("delay", 2, "GIF time a frame is shown in 100ths of a second")
+	hold := flag.Int("hold", 300, "GIF time the last frame is shown in 100ths of a second")
+	paper := flag.Bool("paper", true, "TikZ fills the picture with the paper")
+	optimize := flag.Bool("optimize", false, "join the strokes and order them for the least pen travel, for the plotters: gcode, hpgl and dxf")
+	flag.Parse()
+
+	source, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		panic(err)
+	}
+
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	var document io.WriterTo
+	var recorder *render.Recorder
+	var finish func(r *logo.Runtime)
+	r := logo.NewRuntime()
+	// The printed text would mix with the document on the standard output
+	r.Writer = os.Stderr
+
+	switch *format {
+	case "pdf":
+		pdf := render.NewPDF()
+		pdf.Width, pdf.Height, err = render.PageSize(*page)
+		if err != nil {
+			fail(err)
+		}
+		r.Stub, document, recorder = pdf, pdf, &pdf.Recorder
+	case "gcode":
+		gcode := render.NewGCode()
+		gcode.Scale, gcode.Feed = *scale, *feed
+		gcode.PenUp, gcode.PenDown = *penUp, *penDown
+		gcode.FlipY = *flip
+		r.Stub, document, recorder = gcode, gcode, &gcode.Recorder
+	case "hpgl":
+		hpgl := render.NewHPGL()
+		hpgl.Scale, hpgl.FlipY = *scale, *flip
+		hpgl.Pens, err = render.ParsePens(*pens)
+		if err != nil {
+			fail(err)
+		}
+		r.Stub, document, recorder = hpgl, hpgl, &hpgl.Recorder
+	case "dxf":
+		dxf := render.NewDXF()
+		dxf.Scale, dxf.FlipY = *scale, *flip
+		r.Stub, document, recorder = dxf, dxf, &dxf.Recorder
+	case "tikz":
+		tikz := render.NewTikZ()
+		if set["scale"] {
+			tikz.Scale = *scale
+		}
+		tikz.Paper = *paper
+		r.Stub, document, recorder = tikz, tikz, &tikz.Recorder
+	case "gif":
+		animation := render.NewGIF()
+		animation.Every, animation.Delay, animation.Hold = max(*every, 1), *delay, *hold
+		r.Stub, document = animation, animation
+		finish = animation.Finish
+	case "sixel":
+		sixel := render.NewSixel()
+		r.Stub, document = sixel, sixel
+	case "kitty":
+		kitty := render.NewKitty()
+		r.Stub, document = kitty, kitty
+	default:
+		fail(fmt.Errorf("unknown format %s", *format))
+	}
+
+	if set["seed"] {
+		r.Random.Seed(*seed)
+	}
+	if err := r.Run(string(source)); err != nil {
+		fail(err)
+	}
+
+	if finish != nil {
+		finish(r)
+	}
+
+	if *optimize {
+		// The inks are drawn one after the other, on a screen or paper the
+		// later strokes would no longer cover the earlier ones
+		if !slices.Contains([]string{"gcode", "hpgl", "dxf"}, *format) {
+			fail(fmt.Errorf("-optimize is for the plotters, it does not work with %s", *format))
+		}
+		recorder.Pages = render.Optimize(recorder.Pages)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		out, err = os.Create(*output)
+		if err != nil {
+			fail(err)
+		}
+		defer out.Close()
+	}
+
+	if _, err := document.WriteTo(out); err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -1,13 +1,25 @@
 package main
 
 import (
+	"bytes"
+	"flag"
 	"io"
 	"os"
 
 	"rs.lab/go-logo/logo"
+	"rs.lab/go-logo/render"
 )
 
 func main() {
+	seed := flag.Int64("seed", 0, "seed for RANDOM and PICK, a random one is used when not set")
+	visual := flag.Bool("visual", false, "draw in the terminal with characters")
+	braille := flag.Bool("braille", true, "visual mode with braille dots, half blocks otherwise")
+	columns := flag.Int("columns", 80, "width of the visual mode in characters")
+	animate := flag.Bool("animate", false, "redraw the visual mode in place after every line")
+	delay := flag.Duration("delay", 0, "pause after every line when animated")
+	plain := flag.Bool("plain", false, "visual mode without colors")
+	flag.Parse()
+
 	text, err := io.ReadAll(os.Stdin)
 	if err != nil {
 		panic(err)
@@ -15,7 +27,31 @@ func main() {
 
 	r := logo.NewRuntime()
 	r.Trace = true
+
+	var terminal *render.Terminal
+	var printed bytes.Buffer
+	if *visual {
+		terminal = render.NewTerminal(os.Stdout, max(*columns, 1), *braille)
+		terminal.Animate, terminal.Delay, terminal.Color = *animate, *delay, !*plain
+		r.Stub = terminal
+		// The frames are redrawn in place, any other line in between would
+		// move them up. The trace is left out and the printed text waits for
+		// the last frame.
+		if *animate {
+			r.Trace = false
+			r.Writer = &printed
+		}
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			r.Random.Seed(*seed)
+		}
+	})
 	err = r.Run(string(text))
+	if terminal != nil {
+		terminal.Flush()
+	}
+	os.Stdout.Write(printed.Bytes())
 
 	if err != nil {
 		panic(err)
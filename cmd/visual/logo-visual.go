@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"io"
 	"math"
 	"os"
@@ -75,6 +76,9 @@ func (v *Visual) DrawLine(r *logo.Runtime, x1, y1, x2, y2 int32) {
 }
 
 func main() {
+	seed := flag.Int64("seed", 0, "seed for RANDOM and PICK, a random one is used when not set")
+	flag.Parse()
+
 	source, err := io.ReadAll(os.Stdin)
 	if err != nil {
 		panic(err)
@@ -105,6 +109,11 @@ func main() {
 	r := logo.NewRuntime()
 	// r.Trace = true
 	r.Stub = visual
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			r.Random.Seed(*seed)
+		}
+	})
 	err = r.Run(string(source))
 
 	visual.DrawTurtle(r, 10)
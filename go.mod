@@ -2,4 +2,4 @@ module rs.lab/go-logo
 
 go 1.22.1
 
-require github.com/veandco/go-sdl2 v0.4.39 // indirect
+require github.com/veandco/go-sdl2 v0.4.39
@@ -0,0 +1,93 @@
+package logo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Backend generates the code of a target language. The compiler parses the
+// program and asks the backend for the code of every construct, the inputs
+// arrive as code of the target already. Every piece of code is a statement,
+// a line or more; the ones ending in Begin open a block that End closes.
+// The compiler indents the lines inside a block with Indent, a piece of code
+// with more lines indents the ones inside its own blocks itself.
+//
+// The values only known when the program runs are converted by helpers of
+// the runtime of the target, which fail like the interpreter does.
+type Backend interface {
+	Name() string
+	Supports(keyword string) bool // the commands and reporters the target has
+
+	// The procedures are compiled first, Prologue and Epilogue surround the
+	// main program after them; a Prologue opens a block
+	Prologue() string
+	Epilogue() string
+
+	Indent() string // a level of indentation
+	Empty() string  // the code of a block with nothing in it
+
+	Command(name string, args ...string) string  // a built-in command, like HOME or FORWARD
+	Reporter(name string, args ...string) string // a built-in reporter, like SIN
+	Statement(call string) string                // a call of a procedure as a command
+	Line(line uint32) string                     // keeps the running line, ERROR outputs it
+	Comment(text string) string                  // a comment of a line, the source line with Compiler.Comments
+
+	ProcedureBegin(proc *Procedure, name string, params []string) string
+	ProcedureEnd(proc *Procedure) string
+	Output(value string) string
+	Stop(proc *Procedure) string // fails in a procedure with OUTPUT, it has to output
+
+	RepeatBegin(variable, count string) string
+	ForeachBegin(variable, values string, depth int) string // the item is the input of the template
+	ApplyBegin(values string, depth int) string             // the list items are the inputs of the template
+	IfBegin(condition string) string
+	Else() string
+	End() string // closes REPEAT, FOREACH, APPLY and IF
+	CatchBegin(tag string) string
+	CatchEnd() string
+
+	Number(value float64) string
+	Word(value string) string
+	Boolean(value bool) string
+	List(items []string) string
+	Template(expression string, depth int) string // a function of the slots outputting the expression
+	Slot(index, depth int) string                 // an input of the template at that nesting depth
+
+	Num(value string) string   // a number, a word of digits is one too
+	Color(value string) string // the color of PAPER and INK from its name
+	Pen(value string) string   // the state of the pen from UP or DOWN
+	Count(value string) string // the count of REPEAT, without the fraction
+	Tag(value string) string   // the tag of CATCH and THROW, in upper case
+
+	Arithmetic(left string, operator rune, right string) string // +, -, * and / of numbers, dividing by zero fails
+	Negate(value string) string
+	Compare(left string, operator rune, right string) string // <, > and =, words and lists are equal ignoring the case
+	Call(name string, args []string) string                  // a call of a procedure
+}
+
+// BACKENDS are the targets of the compiler by name
+var BACKENDS = map[string]func() Backend{
+	"js":     func() Backend { return NewJSBackend() },
+	"esm":    func() Backend { return NewJSBackend() }, // the page runtime in a module
+	"go":     func() Backend { return NewGoBackend() },
+	"python": func() Backend { return NewPythonBackend() },
+}
+
+// fill fills the code of the name in with the inputs. The compiler asks for
+// the names it knows, a name or a number of inputs the table does not have is
+// a bug of the backend and fails the compilation.
+func fill(codes map[string]string, name string, args []string) string {
+	code, ok := codes[name]
+	if !ok {
+		panic(fmt.Errorf("no code for %s", name))
+	}
+	if verbs := strings.Count(code, "%s"); verbs != len(args) {
+		panic(fmt.Errorf("the code of %s takes %d inputs, not %d", name, verbs, len(args)))
+	}
+
+	values := make([]any, len(args))
+	for i, arg := range args {
+		values[i] = arg
+	}
+	return fmt.Sprintf(code, values...)
+}
@@ -5,22 +5,84 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"slices"
+	"strconv"
 	"strings"
 )
 
 type CompileCommand func(c *Compiler)
+type CompileReporter func(c *Compiler) string
 
+// The built-in commands, every new Compiler starts with a copy
 var keywords = map[string]CompileCommand{
-	"HOME":    compileHomeCmd,
-	"PAPER":   compilePaperCmd,
-	"INK":     compileInkCmd,
-	"PEN":     compilePenCmd,
-	"REPEAT":  compileRepeatCmd,
-	"LOOP":    compileLoopCmd,
-	"FORWARD": compileForwardCmd,
-	"BACK":    compileBackCmd,
-	"LEFT":    compileLeftCmd,
-	"RIGHT":   compileRightCmd,
+	"HOME":     compileHomeCmd,
+	"PAPER":    compilePaperCmd,
+	"INK":      compileInkCmd,
+	"PEN":      compilePenCmd,
+	"REPEAT":   compileRepeatCmd,
+	"LOOP":     compileLoopCmd,
+	"FORWARD":  compileForwardCmd,
+	"FD":       compileForwardCmd,
+	"BACK":     compileBackCmd,
+	"BK":       compileBackCmd,
+	"LEFT":     compileLeftCmd,
+	"LT":       compileLeftCmd,
+	"RIGHT":    compileRightCmd,
+	"RT":       compileRightCmd,
+	"RERANDOM": compileRerandomCmd,
+	"TO":       compileToCmd,
+	"OUTPUT":   compileOutputCmd,
+	"STOP":     compileStopCmd,
+	"IF":       compileIfCmd,
+	"IFELSE":   compileIfElseCmd,
+	"PRINT":    compilePrintCmd,
+	"PR":       compilePrintCmd,
+	"SHOW":     compileShowCmd,
+	"TYPE":     compileTypeCmd,
+	"RUN":      compileRunCmd,
+	"APPLY":    compileApplyCmd,
+	"FOREACH":  compileForeachCmd,
+	"CATCH":    compileCatchCmd,
+	"THROW":    compileThrowCmd,
+	"PO":       compileWorkspaceCmd,
+	"POTS":     compileWorkspaceCmd,
+	"ERASE":    compileWorkspaceCmd,
+	"SAVE":     compileWorkspaceCmd,
+	"EDIT":     compileWorkspaceCmd,
+}
+
+// The built-in reporters, they compile to expressions
+var reporters = map[string]CompileReporter{
+	"RANDOM":   compileRandomReporter,
+	"PICK":     compilePickReporter,
+	"SIN":      compileSinReporter,
+	"COS":      compileCosReporter,
+	"SQRT":     compileSqrtReporter,
+	"ARCTAN":   compileArctanReporter,
+	"POWER":    compilePowerReporter,
+	"ABS":      compileAbsReporter,
+	"INT":      compileIntReporter,
+	"ROUND":    compileRoundReporter,
+	"MODULO":   compileModuloReporter,
+	"FIRST":    compileFirstReporter,
+	"LAST":     compileLastReporter,
+	"BUTFIRST": compileButFirstReporter,
+	"BF":       compileButFirstReporter,
+	"BUTLAST":  compileButLastReporter,
+	"BL":       compileButLastReporter,
+	"ITEM":     compileItemReporter,
+	"COUNT":    compileCountReporter,
+	"FPUT":     compileFputReporter,
+	"LPUT":     compileLputReporter,
+	"SENTENCE": compileSentenceReporter,
+	"SE":       compileSentenceReporter,
+	"WORD":     compileWordReporter,
+	"EMPTYP":   compileEmptypReporter,
+	"APPLY":    compileApplyReporter,
+	"MAP":      compileMapReporter,
+	"FILTER":   compileFilterReporter,
+	"REDUCE":   compileReduceReporter,
+	"ERROR":    compileErrorReporter,
 }
 
 var colors = map[string]string{
@@ -35,74 +97,402 @@ var colors = map[string]string{
 }
 
 type Compiler struct {
-	Program []ProgramStep
-	writer  *bufio.Writer
-	PC      int
-	vidx    int
-	Trace   bool
+	Program    []ProgramStep
+	Backend    Backend // the target language, JavaScript by default
+	templates  int     // nesting of templates, ? is only valid inside one
+	lines      bool    // keep the running line in the code, ERROR outputs it
+	defining   bool    // compiling the procedures, before the main program
+	keywords   map[string]CompileCommand
+	reporters  map[string]CompileReporter
+	procedures map[string]*Procedure
+	procedure  *Procedure      // the procedure being compiled
+	depth      int             // nesting of loops and instruction lists
+	indent     int             // nesting of the blocks in the code
+	numbers    map[string]bool // the code of the arithmetic, it outputs numbers
+	empty      bool            // nothing emitted since the last block opened
+	returned   bool            // the last statement emitted returns from the procedure
+	writer     *bufio.Writer
+	PC         int
+	vidx       int
+	Trace      bool
+	Comments   bool        // write the source lines as comments in the code
+	commented  ProgramStep // the step of the last source line written
+	Path       []string    // directories LOAD and INCLUDE search, LOGOPATH by default
 }
 
 func compileHomeCmd(c *Compiler) {
 	c.trace("HOME")
-	c.emit("home();")
+	c.emit(c.Backend.Command("HOME"))
 }
 
 func compilePaperCmd(c *Compiler) {
 	c.trace("PAPER")
-	c.emit("paper = '%s';", c.getColor())
+	c.emit(c.Backend.Command("PAPER", c.getColor()))
 }
 
 func compileInkCmd(c *Compiler) {
 	c.trace("INK")
-	c.emit("ink = '%s';", c.getColor())
+	c.emit(c.Backend.Command("INK", c.getColor()))
 }
 
 func compilePenCmd(c *Compiler) {
 	c.trace("PEN")
-	token := c.getParam(TkIdent)
-	value := strings.ToUpper(token.String)
+	value, constant := c.getWord()
+	if !constant {
+		c.emit(c.Backend.Command("PEN", c.Backend.Pen(value)))
+		return
+	}
+
+	value = strings.ToUpper(value)
 	if value == "UP" || value == "DOWN" {
-		c.emit("pendown = %t;", value == "DOWN")
+		c.emit(c.Backend.Command("PEN", c.Backend.Boolean(value == "DOWN")))
 		return
 	}
 
-	c.syntaxError(fmt.Sprintf("invalid parameter in line %d", token.Line))
+	c.syntaxError(fmt.Sprintf("invalid parameter in line %d", c.line()))
 }
 
 func compileForwardCmd(c *Compiler) {
 	c.trace("FORWARD")
-	c.emit("forward(%d);", c.getParam(TkNumber).Number)
+	c.emit(c.Backend.Command("FORWARD", c.getNumber()))
 }
 
 func compileBackCmd(c *Compiler) {
 	c.trace("BACK")
-	c.emit("back(%d);", c.getParam(TkNumber).Number)
+	c.emit(c.Backend.Command("BACK", c.getNumber()))
 }
 
 func compileLeftCmd(c *Compiler) {
 	c.trace("LEFT")
-	c.emit("left(%d);", c.getParam(TkNumber).Number)
+	c.emit(c.Backend.Command("LEFT", c.getNumber()))
 }
 
 func compileRightCmd(c *Compiler) {
 	c.trace("RIGHT")
-	c.emit("right(%d);", c.getParam(TkNumber).Number)
+	c.emit(c.Backend.Command("RIGHT", c.getNumber()))
 }
 
 func compileRepeatCmd(c *Compiler) {
 	c.trace("REPEAT")
-	token := c.getParam(TkNumber)
-	if token.Number <= 0 || token.Number >= 65536 {
-		c.syntaxError(fmt.Sprintf("the count is too small or too large number in line %d", token.Line))
+	count, constant := c.compileParam(TkNumber)
+	if !constant {
+		count = c.Backend.Count(count)
+	} else {
+		// the fraction is dropped, like the interpreter does
+		number := int(c.Program[c.PC-1].Number)
+		if number <= 0 || number >= 65536 {
+			c.syntaxError(fmt.Sprintf("the count is too small or too large number in line %d", c.line()))
+		}
+		count = c.Backend.Number(float64(number))
 	}
 
-	variable := c.nextVar()
-	c.emit("for(let %s=0;%s<%d;++%s){", variable, variable, token.Number, variable)
+	c.open(c.Backend.RepeatBegin(c.nextVar(), count))
+	c.depth += 1
 }
 
 func compileLoopCmd(c *Compiler) {
 	c.trace("LOOP")
-	c.emit("}")
+	c.close(c.Backend.End())
+	c.depth -= 1
+}
+
+func compileToCmd(c *Compiler) {
+	c.trace("TO")
+	name := c.getParam(TkIdent)
+	if c.depth != 0 || c.procedure != nil {
+		c.syntaxError(fmt.Sprintf("TO is only allowed at the top level in line %d", name.Line))
+	}
+
+	proc := c.procedures[strings.ToUpper(name.String)]
+	if !c.defining {
+		c.PC = proc.End + 1 // compiled before the main program
+		return
+	}
+
+	params := make([]string, len(proc.Params))
+	for i, param := range proc.Params {
+		params[i] = jsName("arg_", param)
+	}
+
+	c.comment(c.PC - 2) // TO
+
+	c.open(c.Backend.ProcedureBegin(proc, jsName("proc_", proc.Name), params))
+	c.procedure = proc
+	c.PC = proc.Start
+	for c.PC < proc.End {
+		c.statement()
+	}
+	c.PC = proc.End + 1
+	c.procedure = nil
+	// Getting to the end without OUTPUT fails like STOP, after a return the
+	// code could not be reached
+	if proc.Output && !c.returned {
+		c.emit(c.Backend.Stop(proc))
+	}
+	c.close(c.Backend.ProcedureEnd(proc))
+}
+
+func compileOutputCmd(c *Compiler) {
+	c.trace("OUTPUT")
+	if c.procedure == nil {
+		c.syntaxError(fmt.Sprintf("OUTPUT outside of a procedure in line %d", c.line()))
+	}
+	c.emit(c.Backend.Output(c.evaluate()))
+	c.returned = true
+}
+
+func compileStopCmd(c *Compiler) {
+	c.trace("STOP")
+	if c.procedure == nil {
+		c.syntaxError(fmt.Sprintf("STOP outside of a procedure in line %d", c.line()))
+	}
+	c.emit(c.Backend.Stop(c.procedure))
+	c.returned = true
+}
+
+func compileIfCmd(c *Compiler) {
+	c.trace("IF")
+	c.open(c.Backend.IfBegin(c.evaluate()))
+	c.block()
+	c.close(c.Backend.End())
+}
+
+func compileIfElseCmd(c *Compiler) {
+	c.trace("IFELSE")
+	c.open(c.Backend.IfBegin(c.evaluate()))
+	c.block()
+	returned := c.returned
+	c.close(c.Backend.Else())
+	c.open("")
+	c.block()
+	returned = returned && c.returned // both ways return
+	c.close(c.Backend.End())
+	c.returned = returned
+}
+
+func compilePrintCmd(c *Compiler) {
+	c.trace("PRINT")
+	c.emit(c.Backend.Command("PRINT", c.evaluate()))
+}
+
+func compileShowCmd(c *Compiler) {
+	c.trace("SHOW")
+	c.emit(c.Backend.Command("SHOW", c.evaluate()))
+}
+
+func compileTypeCmd(c *Compiler) {
+	c.trace("TYPE")
+	c.emit(c.Backend.Command("TYPE", c.evaluate()))
+}
+
+// compileRunCmd compiles the instruction list in place, so it has to be
+// written literally
+func compileRunCmd(c *Compiler) {
+	c.trace("RUN")
+	if c.isEOP() || c.Program[c.PC].Token != TkListOpen {
+		c.syntaxError(fmt.Sprintf("RUN needs a literal instruction list in compiled programs in line %d", c.line()))
+	}
+	c.block()
+}
+
+func compileApplyCmd(c *Compiler) {
+	c.trace("APPLY")
+	template := c.PC
+	c.skipTemplate()
+	inputs := c.evaluate()
+	end := c.PC
+
+	c.open(c.Backend.ApplyBegin(inputs, c.templates+1))
+	c.PC = template
+	c.templateStatements()
+	c.PC = end
+	c.close(c.Backend.End())
+}
+
+func compileForeachCmd(c *Compiler) {
+	c.trace("FOREACH")
+	c.open(c.Backend.ForeachBegin(c.nextVar(), c.evaluate(), c.templates+1))
+	c.depth += 1
+	c.templateStatements()
+	c.depth -= 1
+	c.close(c.Backend.End())
+}
+
+// compileCatchCmd compiles the instruction list in place, so it has to be
+// written literally
+func compileCatchCmd(c *Compiler) {
+	c.trace("CATCH")
+	tag := c.getTag()
+	if c.isEOP() || c.Program[c.PC].Token != TkListOpen {
+		c.syntaxError(fmt.Sprintf("CATCH needs a literal instruction list in compiled programs in line %d", c.line()))
+	}
+
+	c.open(c.Backend.CatchBegin(tag))
+	c.block()
+	c.close(c.Backend.CatchEnd())
+}
+
+func compileThrowCmd(c *Compiler) {
+	c.trace("THROW")
+	c.emit(c.Backend.Command("THROW", c.getTag()))
+}
+
+// compileWorkspaceCmd rejects the workspace commands, a compiled program has
+// no workspace
+func compileWorkspaceCmd(c *Compiler) {
+	cmd := c.Program[c.PC-1]
+	c.syntaxError(fmt.Sprintf("%s only works in the interpreter in line %d", strings.ToUpper(cmd.String), cmd.Line))
+}
+
+func compileRerandomCmd(c *Compiler) {
+	c.trace("RERANDOM")
+	c.emit(c.Backend.Command("RERANDOM", c.getNumber()))
+}
+
+func compileRandomReporter(c *Compiler) string {
+	c.trace("RANDOM")
+	return c.Backend.Reporter("RANDOM", c.getNumber())
+}
+
+func compilePickReporter(c *Compiler) string {
+	c.trace("PICK")
+	return c.Backend.Reporter("PICK", c.evaluate())
+}
+
+func compileSinReporter(c *Compiler) string {
+	c.trace("SIN")
+	return c.Backend.Reporter("SIN", c.getNumber())
+}
+
+func compileCosReporter(c *Compiler) string {
+	c.trace("COS")
+	return c.Backend.Reporter("COS", c.getNumber())
+}
+
+func compileSqrtReporter(c *Compiler) string {
+	c.trace("SQRT")
+	return c.Backend.Reporter("SQRT", c.getNumber())
+}
+
+func compileArctanReporter(c *Compiler) string {
+	c.trace("ARCTAN")
+	return c.Backend.Reporter("ARCTAN", c.getNumber())
+}
+
+func compilePowerReporter(c *Compiler) string {
+	c.trace("POWER")
+	base := c.getNumber()
+	return c.Backend.Reporter("POWER", base, c.getNumber())
+}
+
+func compileAbsReporter(c *Compiler) string {
+	c.trace("ABS")
+	return c.Backend.Reporter("ABS", c.getNumber())
+}
+
+func compileIntReporter(c *Compiler) string {
+	c.trace("INT")
+	return c.Backend.Reporter("INT", c.getNumber())
+}
+
+func compileRoundReporter(c *Compiler) string {
+	c.trace("ROUND")
+	return c.Backend.Reporter("ROUND", c.getNumber())
+}
+
+func compileModuloReporter(c *Compiler) string {
+	c.trace("MODULO")
+	a := c.getNumber()
+	return c.Backend.Reporter("MODULO", a, c.getNumber())
+}
+
+func compileFirstReporter(c *Compiler) string {
+	c.trace("FIRST")
+	return c.Backend.Reporter("FIRST", c.evaluate())
+}
+
+func compileLastReporter(c *Compiler) string {
+	c.trace("LAST")
+	return c.Backend.Reporter("LAST", c.evaluate())
+}
+
+func compileButFirstReporter(c *Compiler) string {
+	c.trace("BUTFIRST")
+	return c.Backend.Reporter("BUTFIRST", c.evaluate())
+}
+
+func compileButLastReporter(c *Compiler) string {
+	c.trace("BUTLAST")
+	return c.Backend.Reporter("BUTLAST", c.evaluate())
+}
+
+func compileItemReporter(c *Compiler) string {
+	c.trace("ITEM")
+	index := c.getNumber()
+	return c.Backend.Reporter("ITEM", index, c.evaluate())
+}
+
+func compileCountReporter(c *Compiler) string {
+	c.trace("COUNT")
+	return c.Backend.Reporter("COUNT", c.evaluate())
+}
+
+func compileFputReporter(c *Compiler) string {
+	c.trace("FPUT")
+	value := c.evaluate()
+	return c.Backend.Reporter("FPUT", value, c.evaluate())
+}
+
+func compileLputReporter(c *Compiler) string {
+	c.trace("LPUT")
+	value := c.evaluate()
+	return c.Backend.Reporter("LPUT", value, c.evaluate())
+}
+
+func compileSentenceReporter(c *Compiler) string {
+	c.trace("SENTENCE")
+	first := c.evaluate()
+	return c.Backend.Reporter("SENTENCE", first, c.evaluate())
+}
+
+func compileWordReporter(c *Compiler) string {
+	c.trace("WORD")
+	first := c.evaluate()
+	return c.Backend.Reporter("WORD", first, c.evaluate())
+}
+
+func compileEmptypReporter(c *Compiler) string {
+	c.trace("EMPTYP")
+	return c.Backend.Reporter("EMPTYP", c.evaluate())
+}
+
+func compileErrorReporter(c *Compiler) string {
+	c.trace("ERROR")
+	return c.Backend.Reporter("ERROR")
+}
+
+func compileApplyReporter(c *Compiler) string {
+	c.trace("APPLY")
+	template := c.templateFunction()
+	return c.Backend.Reporter("APPLY", template, c.evaluate())
+}
+
+func compileMapReporter(c *Compiler) string {
+	c.trace("MAP")
+	template := c.templateFunction()
+	return c.Backend.Reporter("MAP", template, c.evaluate())
+}
+
+func compileFilterReporter(c *Compiler) string {
+	c.trace("FILTER")
+	template := c.templateFunction()
+	return c.Backend.Reporter("FILTER", template, c.evaluate())
+}
+
+func compileReduceReporter(c *Compiler) string {
+	c.trace("REDUCE")
+	template := c.templateFunction()
+	return c.Backend.Reporter("REDUCE", template, c.evaluate())
 }
 
 func (c *Compiler) trace(msg string) {
@@ -112,6 +502,9 @@ func (c *Compiler) trace(msg string) {
 }
 
 func (c *Compiler) CompilerError(err error) {
+	if c.PC > 0 && c.PC <= len(c.Program) {
+		err = fileError(c.Program[c.PC-1].File, err)
+	}
 	panic(err)
 }
 
@@ -120,13 +513,25 @@ func (c *Compiler) syntaxError(err string) {
 }
 
 func (c *Compiler) getColor() string {
-	token := c.getParam(TkIdent)
-	color := strings.ToUpper(token.String)
-	if value, ok := colors[color]; ok {
-		return value
+	value, constant := c.getWord()
+	if !constant {
+		return c.Backend.Color(value)
+	}
+
+	if color, ok := colors[strings.ToUpper(value)]; ok {
+		return c.Backend.Word(color)
 	}
-	c.syntaxError(fmt.Sprintf("unrecognized color in line %d", token.Line))
-	return "black" // Dummy color
+	c.syntaxError(fmt.Sprintf("unrecognized color in line %d", c.line()))
+	return c.Backend.Word("black") // Dummy color
+}
+
+// getTag compiles the tag of CATCH and THROW, tags ignore the case
+func (c *Compiler) getTag() string {
+	value, constant := c.getWord()
+	if constant {
+		return c.Backend.Word(strings.ToUpper(value))
+	}
+	return c.Backend.Tag(value)
 }
 
 func (c *Compiler) isEOP() bool {
@@ -155,13 +560,405 @@ func (c *Compiler) getParam(expected Token) ProgramStep {
 	return param
 }
 
+// compileParam compiles a parameter of the expected type to an expression of
+// the target, constant reports whether it was given directly as a single
+// literal
+func (c *Compiler) compileParam(expected Token) (value string, constant bool) {
+	if expected == TkIdent && c.isWord() {
+		return c.Backend.Word(c.next().String), true
+	}
+
+	start := c.PC
+	value = c.evaluate()
+	if c.PC == start+1 && c.Program[start].Token == TkNumber {
+		return value, true
+	}
+
+	if expected == TkNumber {
+		value = c.numeric(value)
+	}
+	return value, false
+}
+
+// isWord checks whether the next step is a plain word, not the name of a
+// reporter or a procedure
+func (c *Compiler) isWord() bool {
+	if c.isEOP() || c.Program[c.PC].Token != TkIdent {
+		return false
+	}
+
+	name := strings.ToUpper(c.Program[c.PC].String)
+	_, reporter := c.reporters[name]
+	_, procedure := c.procedures[name]
+	return !reporter && !procedure
+}
+
+// report compiles a call of the reporter or the procedure named by the step,
+// if there is one
+func (c *Compiler) report(step ProgramStep) (string, bool) {
+	name := strings.ToUpper(step.String)
+	if fn, ok := c.reporters[name]; ok {
+		c.supported(name)
+		return fn(c), true
+	}
+
+	if proc, ok := c.procedures[name]; ok {
+		if !proc.Output {
+			c.syntaxError(fmt.Sprintf("%s does not output in line %d", name, step.Line))
+		}
+		return c.call(proc), true
+	}
+
+	return "", false
+}
+
+// evaluate compiles an expression with an optional comparison
+func (c *Compiler) evaluate() string {
+	value := c.expression()
+	switch {
+	case c.isLiteral('<') || c.isLiteral('>'):
+		operator := c.next().Literal
+		return c.Backend.Compare(c.numeric(value), operator, c.numeric(c.expression()))
+	case c.isLiteral('='):
+		c.next()
+		return c.Backend.Compare(value, '=', c.expression())
+	}
+	return value
+}
+
+// expression compiles sums and differences of terms
+func (c *Compiler) expression() string {
+	value := c.term()
+	for c.isLiteral('+') || c.isLiteral('-') {
+		operator := c.next().Literal
+		value = c.arithmetic(c.Backend.Arithmetic(c.numeric(value), operator, c.numeric(c.term())))
+	}
+	return value
+}
+
+// term compiles products and quotients of factors
+func (c *Compiler) term() string {
+	value := c.factor()
+	for {
+		switch {
+		case c.isLiteral('*'):
+			c.next()
+			value = c.arithmetic(c.Backend.Arithmetic(c.numeric(value), '*', c.numeric(c.factor())))
+		case c.isLiteral('/'):
+			c.next()
+			value = c.arithmetic(c.Backend.Arithmetic(c.numeric(value), '/', c.numeric(c.factor())))
+		default:
+			return value
+		}
+	}
+}
+
+// factor compiles a number, a variable, a reporter, a negated factor or an
+// expression in parentheses
+func (c *Compiler) factor() string {
+	param := c.next()
+	switch {
+	case param.Token == TkNumber:
+		return c.Backend.Number(param.Number)
+	case param.Token == TkWord || param.Token == TkString:
+		return c.Backend.Word(param.String)
+	case param.Token == TkListOpen:
+		return c.listLiteral()
+	case param.Token == TkValue:
+		return param.String
+	case param.Token == TkLiteral && param.Literal == '?':
+		return c.slot(0)
+	case param.Token == TkLiteral && param.Literal == '-':
+		return c.arithmetic(c.Backend.Negate(c.numeric(c.factor())))
+	case param.Token == TkLiteral && param.Literal == '(':
+		value := c.evaluate()
+		if closing := c.next(); closing.Token != TkLiteral || closing.Literal != ')' {
+			c.syntaxError(fmt.Sprintf("missing closing parenthesis in line %d", param.Line))
+		}
+		return value
+	case param.Token == TkLiteral && param.Literal == ':':
+		return c.variable(c.getParam(TkIdent))
+	case param.Token == TkIdent:
+		if index, ok := isSlot(param); ok {
+			return c.slot(index)
+		}
+		if value, ok := c.report(param); ok {
+			return value
+		}
+		c.syntaxError(fmt.Sprintf("unknown reporter %s in line %d", strings.ToUpper(param.String), param.Line))
+	}
+
+	c.syntaxError(fmt.Sprintf("unexpected token %d in line %d", param.Token, param.Line))
+	return "0" // Dummy value
+}
+
+// variable compiles a reference to an input of the procedure being compiled
+func (c *Compiler) variable(name ProgramStep) string {
+	if c.procedure != nil {
+		for _, param := range c.procedure.Params {
+			if param == strings.ToUpper(name.String) {
+				return jsName("arg_", param)
+			}
+		}
+	}
+
+	c.syntaxError(fmt.Sprintf("unknown variable %s in line %d", name.String, name.Line))
+	return "0" // Dummy value
+}
+
+// jsName turns a Logo name to an identifier valid in all targets
+func jsName(prefix, name string) string {
+	var sb strings.Builder
+	sb.WriteString(prefix)
+	for _, ch := range strings.ToLower(name) {
+		if (ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9') {
+			sb.WriteRune(ch)
+		} else {
+			sb.WriteString(fmt.Sprintf("_%x", ch))
+		}
+	}
+	return sb.String()
+}
+
+// isLiteral checks the next step without consuming it
+func (c *Compiler) isLiteral(literal rune) bool {
+	return !c.isEOP() && c.Program[c.PC].Token == TkLiteral && c.Program[c.PC].Literal == literal
+}
+
+// statement compiles a single command or procedure call
+func (c *Compiler) statement() {
+	p := c.next()
+
+	if p.Token != TkIdent {
+		c.syntaxError(fmt.Sprintf("unexpected token %d in line %d", p.Token, p.Line))
+	}
+
+	cmd := strings.ToUpper(p.String)
+	if cmd != "TO" { // a procedure is written when it is defined
+		c.comment(c.PC - 1)
+		if c.lines {
+			c.emit(c.Backend.Line(p.Line))
+		}
+	}
+
+	if fn, ok := c.keywords[cmd]; ok {
+		c.supported(cmd)
+		fn(c)
+	} else if proc, ok := c.procedures[cmd]; ok {
+		if proc.Output {
+			c.syntaxError(fmt.Sprintf("you don't say what to do with the output of %s in line %d", cmd, p.Line))
+		}
+		c.emit(c.Backend.Statement(c.call(proc)))
+	} else {
+		c.syntaxError(fmt.Sprintf("unknown keyword in line %d", p.Line))
+	}
+}
+
+// supported fails on the commands and reporters the target does not have
+func (c *Compiler) supported(name string) {
+	if !c.Backend.Supports(name) {
+		c.syntaxError(fmt.Sprintf("%s is not supported by the %s target in line %d", name, c.Backend.Name(), c.line()))
+	}
+}
+
+// block compiles the statements of a bracketed instruction list
+func (c *Compiler) block() {
+	if open := c.next(); open.Token != TkListOpen {
+		c.syntaxError(fmt.Sprintf("expected instruction list in line %d", open.Line))
+	}
+
+	c.depth += 1
+	for c.isEOP() || c.Program[c.PC].Token != TkListClose {
+		c.statement()
+	}
+	c.next()
+	c.depth -= 1
+}
+
+// call compiles a procedure call with its inputs
+func (c *Compiler) call(proc *Procedure) string {
+	c.trace(proc.Name)
+	args := make([]string, len(proc.Params))
+	for i := range proc.Params {
+		args[i] = c.evaluate()
+	}
+	return c.Backend.Call(jsName("proc_", proc.Name), args)
+}
+
+func (c *Compiler) getNumber() string {
+	value, _ := c.compileParam(TkNumber)
+	return value
+}
+
+// getWord returns the word itself when it is a constant, so it can be checked
+// at compile time, and an expression of the target otherwise
+func (c *Compiler) getWord() (value string, constant bool) {
+	value, constant = c.compileParam(TkIdent)
+	if constant {
+		value = c.Program[c.PC-1].String
+	}
+	return
+}
+
+// line returns the source line of the last consumed step
+func (c *Compiler) line() uint32 {
+	return c.Program[c.PC-1].Line
+}
+
+// listLiteral compiles the items of a list up to the closing bracket, nothing
+// in a literal list is evaluated
+func (c *Compiler) listLiteral() string {
+	items := []string{}
+	for {
+		item := c.next()
+		switch item.Token {
+		case TkListClose:
+			return c.Backend.List(items)
+		case TkListOpen:
+			items = append(items, c.listLiteral())
+		case TkNumber:
+			items = append(items, c.Backend.Number(item.Number))
+		case TkLiteral:
+			items = append(items, c.Backend.Word(string(item.Literal)))
+		case TkWord:
+			items = append(items, c.Backend.Word("\""+item.String))
+		case TkValue:
+			items = append(items, item.String)
+		default:
+			items = append(items, c.Backend.Word(item.String))
+		}
+	}
+}
+
+// Templates compile to functions taking their inputs as the slots, a
+// procedure name gets this many slots, as many as a template can use
+const templateSlots = 9
+
+// templateFunction compiles a literal template to a function
+func (c *Compiler) templateFunction() string {
+	c.templates += 1
+	defer func() { c.templates -= 1 }()
+
+	name := c.next()
+	switch name.Token {
+	case TkListOpen:
+		value := c.evaluate()
+		if closing := c.next(); closing.Token != TkListClose {
+			c.syntaxError(fmt.Sprintf("too much inside the template in line %d", closing.Line))
+		}
+		return c.Backend.Template(value, c.templates)
+	case TkWord:
+		var value string
+		c.compileSteps(c.nameSteps(name), func() {
+			value = c.evaluate()
+		})
+		return c.Backend.Template(value, c.templates)
+	}
+
+	c.syntaxError(fmt.Sprintf("templates have to be written literally in compiled programs in line %d", name.Line))
+	return "" // Dummy value
+}
+
+// templateStatements compiles a literal template as instructions, the inputs
+// have to be in the slots already
+func (c *Compiler) templateStatements() {
+	c.templates += 1
+	defer func() { c.templates -= 1 }()
+
+	switch {
+	case !c.isEOP() && c.Program[c.PC].Token == TkListOpen:
+		c.block()
+	case !c.isEOP() && c.Program[c.PC].Token == TkWord:
+		c.compileSteps(c.nameSteps(c.next()), func() {
+			c.statement()
+		})
+	default:
+		c.syntaxError(fmt.Sprintf("templates have to be written literally in compiled programs in line %d", c.line()))
+	}
+}
+
+// skipTemplate steps over a literal template
+func (c *Compiler) skipTemplate() {
+	if step := c.next(); step.Token != TkListOpen {
+		return
+	}
+
+	for depth := 1; depth > 0; {
+		switch c.next().Token {
+		case TkListOpen:
+			depth += 1
+		case TkListClose:
+			depth -= 1
+		}
+	}
+}
+
+// nameSteps makes a call of the named procedure or primitive, with the
+// template inputs as its parameters
+func (c *Compiler) nameSteps(name ProgramStep) []ProgramStep {
+	steps := []ProgramStep{{Token: TkIdent, String: name.String, Line: name.Line, File: name.File}}
+	for i := 0; i < templateSlots; i++ {
+		steps = append(steps, ProgramStep{Token: TkValue, String: c.Backend.Slot(i, c.templates), Line: name.Line, File: name.File})
+	}
+	return steps
+}
+
+// compileSteps makes the steps the compiled program for the time of fn
+func (c *Compiler) compileSteps(steps []ProgramStep, fn func()) {
+	program, pc := c.Program, c.PC
+	defer func() {
+		c.Program, c.PC = program, pc
+	}()
+
+	c.Program, c.PC = steps, 0
+	fn()
+}
+
+func (c *Compiler) slot(index int) string {
+	if c.templates == 0 {
+		c.syntaxError(fmt.Sprintf("? outside of a template in line %d", c.line()))
+	}
+	return c.Backend.Slot(index, c.templates)
+}
+
+// numeric makes sure an expression outputs a number, literals and arithmetic
+// do already
+func (c *Compiler) numeric(value string) string {
+	if _, err := strconv.ParseFloat(value, 64); err == nil || c.numbers[value] {
+		return value
+	}
+	return c.Backend.Num(value)
+}
+
+// arithmetic marks the code of an operation, numeric knows it outputs a
+// number
+func (c *Compiler) arithmetic(code string) string {
+	c.numbers[code] = true
+	return code
+}
+
 func NewCompiler(writer *bufio.Writer) *Compiler {
+	commands := make(map[string]CompileCommand, len(keywords))
+	for name, fn := range keywords {
+		commands[name] = fn
+	}
+
+	outputs := make(map[string]CompileReporter, len(reporters))
+	for name, fn := range reporters {
+		outputs[name] = fn
+	}
+
 	return &Compiler{
-		Program: []ProgramStep{},
-		PC:      0,
-		vidx:    0,
-		Trace:   false,
-		writer:  writer,
+		keywords:  commands,
+		reporters: outputs,
+		Program:   []ProgramStep{},
+		PC:        0,
+		vidx:      0,
+		numbers:   map[string]bool{},
+		Trace:     false,
+		writer:    writer,
+		Path:      searchPath(),
+		Backend:   NewJSBackend(),
 	}
 }
 
@@ -170,63 +967,191 @@ func (c *Compiler) nextVar() string {
 	return fmt.Sprintf("v%d", c.vidx)
 }
 
-func (c *Compiler) emit(format string, args ...any) {
-	_, err := c.writer.WriteString(fmt.Sprintf(format, args...))
-	if err != nil {
-		c.CompilerError(err)
+// Register adds a primitive to this compiler only. Primitives without a code
+// generator are accepted, but using them fails the compilation.
+func (c *Compiler) Register(p Primitive) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+
+	name := p.keyword()
+	c.keywords[name] = func(c *Compiler) {
+		c.trace(name)
+		if p.Compile == nil {
+			c.syntaxError(fmt.Sprintf("%s cannot be compiled in line %d", name, c.Program[c.PC-1].Line))
+		}
+		if err := p.Compile(c, c.getArgs(p.Params)); err != nil {
+			c.CompilerError(err)
+		}
 	}
+
+	return nil
 }
 
-func (c *Compiler) Compile(program string) error {
-	l := NewLexer(program)
-	// l.Debug = true
-	c.Program = []ProgramStep{}
+func (c *Compiler) getArgs(params []Token) []string {
+	args := make([]string, len(params))
+	for i, expected := range params {
+		args[i], _ = c.compileParam(expected)
+	}
 
-	// Parsing the source, building the program steps
-	for {
-		token, err := l.NextToken()
+	return args
+}
+
+// Emit writes generated code, it is meant to be used by registered primitives.
+// The code is in the language of c.Backend, a primitive checks its Name.
+func (c *Compiler) Emit(format string, args ...any) {
+	c.emit(fmt.Sprintf(format, args...))
+}
+
+// emit writes the code as a line of its own, a construct the target has no
+// code for is left out
+func (c *Compiler) emit(code string) {
+	if code == "" {
+		return
+	}
+
+	indent := strings.Repeat(c.Backend.Indent(), c.indent)
+	for _, line := range strings.Split(code, "\n") {
+		if line != "" {
+			line = indent + line
+		}
+		_, err := c.writer.WriteString(line + "\n")
 		if err != nil {
-			return err
+			c.CompilerError(err)
 		}
+	}
+	c.empty = false
+	c.returned = false
+}
 
-		if token == TkEOF {
-			break
-		}
+// comment writes the source line of the step as a comment, every line once.
+// The line is rebuilt from its steps, in the canonical form of
+// FormatProcedure.
+func (c *Compiler) comment(pc int) {
+	step := c.Program[pc]
+	if !c.Comments || (step.Line == c.commented.Line && step.File == c.commented.File) {
+		return
+	}
+	c.commented = step
 
-		switch token {
-		case TkIdent:
-			c.Program = append(c.Program, ProgramStep{Token: token, String: l.String, Line: l.Line})
-		case TkNumber:
-			c.Program = append(c.Program, ProgramStep{Token: token, Number: l.Number, Line: l.Line})
-		case TkLiteral: // skipped
-		case TkEOL: // skipped
-		case TkComment: // skipped
-		default:
-			return fmt.Errorf("invalid token %d in line %d", token, l.Line)
+	sameLine := func(other ProgramStep) bool {
+		return other.Line == step.Line && other.File == step.File
+	}
+	start, end := pc, pc+1
+	for start > 0 && sameLine(c.Program[start-1]) {
+		start -= 1
+	}
+	for end < len(c.Program) && sameLine(c.Program[end]) {
+		end += 1
+	}
+
+	var sb strings.Builder
+	for i := start; i < end; i++ {
+		if i > start && !glued(c.Program[i-1], c.Program[i]) {
+			sb.WriteString(" ")
 		}
+		sb.WriteString(formatStep(c.Program[i]))
 	}
 
-	// Compile the program
-	c.PC = 0 // reset
-	for {
-		p := c.next()
+	text := fmt.Sprintf("line %d: %s", step.Line, strings.ReplaceAll(sb.String(), "\n", " "))
+	if step.File != "" {
+		text = step.File + ", " + text
+	}
+	empty, returned := c.empty, c.returned // a comment is not a statement
+	c.emit(c.Backend.Comment(text))
+	c.empty, c.returned = empty, returned
+}
 
-		if p.Token != TkIdent {
-			c.syntaxError(fmt.Sprintf("unexpected token %d in line %d", p.Token, p.Line))
-		}
+// open emits the code opening a block, the code inside it is indented
+func (c *Compiler) open(code string) {
+	c.emit(code)
+	c.indent += 1
+	c.empty = true
+}
+
+// close emits the code closing a block, an empty block gets the code the
+// target needs there
+func (c *Compiler) close(code string) {
+	if c.empty {
+		c.emit(c.Backend.Empty())
+	}
+	c.indent -= 1
+	c.emit(code)
+	c.returned = false // even when the target has no code for the end
+}
 
-		cmd := strings.ToUpper(p.String)
+func (c *Compiler) Compile(program string) (err error) {
+	// Parsing the source and the loaded files, building the program steps
+	steps, err := parse(program, c.Path)
+	if err != nil {
+		return err
+	}
+	c.Program = steps
 
-		if fn, ok := keywords[cmd]; ok {
-			fn(c)
-		} else {
-			c.syntaxError(fmt.Sprintf("unknown keyword in line %d", p.Line))
+	procedures, err := scanProcedures(c.Program)
+	if err != nil {
+		return err
+	}
+	c.procedures = procedures
+
+	for name, proc := range c.procedures {
+		_, keyword := c.keywords[name]
+		_, reporter := c.reporters[name]
+		if keyword || reporter {
+			return fmt.Errorf("syntax error: %s is a primitive and cannot be redefined in line %d", name, proc.Line)
+		}
+	}
+
+	// Compiling the program, compiler errors unwind the Go stack up to here
+	defer func() {
+		if e := recover(); e != nil {
+			if failure, ok := e.(error); ok && !isGoRuntimeError(failure) {
+				err = failure
+				return
+			}
+			panic(e)
 		}
+	}()
 
-		if c.isEOP() {
-			break
+	c.lines = false
+	for _, step := range c.Program {
+		if step.Token == TkIdent && strings.ToUpper(step.String) == "ERROR" {
+			c.lines = true
 		}
 	}
 
+	// The procedures first, so targets that define functions in order can
+	// call them from anywhere
+	defined := make([]*Procedure, 0, len(c.procedures))
+	for _, proc := range c.procedures {
+		defined = append(defined, proc)
+	}
+	slices.SortFunc(defined, func(a, b *Procedure) int {
+		return a.Start - b.Start
+	})
+	c.indent = 0
+	c.commented = ProgramStep{}
+	c.defining = true
+	for _, proc := range defined {
+		c.PC = proc.Start - 2*len(proc.Params) - 1 // the name after TO
+		compileToCmd(c)
+	}
+	c.defining = false
+
+	// Compile the program, in a block of its own if the target has a prologue
+	prologue := c.Backend.Prologue()
+	if prologue != "" {
+		c.open(prologue)
+	}
+	c.PC = 0 // reset
+	for !c.isEOP() {
+		c.statement()
+	}
+	if prologue != "" {
+		c.close(c.Backend.Epilogue())
+	} else {
+		c.emit(c.Backend.Epilogue())
+	}
+
 	return nil
 }
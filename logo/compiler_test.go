@@ -0,0 +1,105 @@
+package logo
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// compile compiles the program for the backend and returns the code
+func compile(backend Backend, program string) (string, error) {
+	var sb strings.Builder
+	writer := bufio.NewWriter(&sb)
+	c := NewCompiler(writer)
+	c.Backend = backend
+
+	if err := c.Compile(program); err != nil {
+		return "", err
+	}
+	writer.Flush()
+	return sb.String(), nil
+}
+
+func TestCompileRepeatCount(t *testing.T) {
+	for name, backend := range BACKENDS {
+		code, err := compile(backend(), "repeat 2.5 print \"x loop")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if strings.Contains(code, "2.5") {
+			t.Errorf("%s: the fraction of the count is kept:\n%s", name, code)
+		}
+
+		_, err = compile(backend(), "repeat 0.5 print \"x loop")
+		if err == nil || !strings.Contains(err.Error(), "the count is too small or too large number in line 1") {
+			t.Errorf("%s: repeat 0.5 compiled, error %v", name, err)
+		}
+	}
+}
+
+func TestCompileStopWithoutOutput(t *testing.T) {
+	programs := []struct {
+		program  string
+		failures int
+	}{
+		{"to f :x if :x > 3 [output 1] print 2 end print f 1", 1},            // the end
+		{"to f :x if :x > 3 [output 1] print 2 stop end print f 1", 1},       // STOP, the end is not reached
+		{"to f :x if :x > 3 [output 1] output 2 end print f 1", 0},           // OUTPUT, nor here
+		{"to f :x ifelse :x > 3 [output 1] [output 2] end print f 1", 0},     // both ways output
+		{"to f :x ifelse :x > 3 [output 1] [print 2] end print f 1", 1},      // one does not
+		{"to f :x if :x > 3 [stop] repeat 2 output 1 loop end print f 1", 2}, // STOP and after the loop
+		{"to f :x catch \"error [output 1 / :x] output 0 end print f 1", 0},  // CATCH inside
+	}
+
+	for name, backend := range BACKENDS {
+		for _, test := range programs {
+			program := test.program
+			if !backend().Supports("CATCH") && strings.Contains(program, "catch") {
+				continue
+			}
+			code, err := compile(backend(), program)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if n := strings.Count(code, "F did not output"); n != test.failures {
+				t.Errorf("%s: %s: %d failures, want %d:\n%s", name, program, n, test.failures, code)
+			}
+		}
+	}
+}
+
+func TestCompileWordIsNotArithmetic(t *testing.T) {
+	code, err := compile(NewJSBackend(), "print (word 1 2) + 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(code, "num((word(1) + word(2)))") {
+		t.Errorf("WORD is taken for a number:\n%s", code)
+	}
+}
+
+func TestBackendUnknownCode(t *testing.T) {
+	fails := func(code func() string) (err error) {
+		defer func() {
+			if e := recover(); e != nil {
+				err = fmt.Errorf("%v", e)
+			}
+		}()
+		code()
+		return nil
+	}
+
+	for name, backend := range BACKENDS {
+		b := backend()
+		if err := fails(func() string { return b.Command("NOWHERE", "1") }); err == nil || err.Error() != "no code for NOWHERE" {
+			t.Errorf("%s: unknown command, error %v", name, err)
+		}
+		if err := fails(func() string { return b.Reporter("POWER", "2") }); err == nil || err.Error() != "the code of POWER takes 2 inputs, not 1" {
+			t.Errorf("%s: missing input, error %v", name, err)
+		}
+		if err := fails(func() string { return b.Command("HOME", "1") }); err == nil || err.Error() != "the code of HOME takes 0 inputs, not 1" {
+			t.Errorf("%s: extra input, error %v", name, err)
+		}
+	}
+}
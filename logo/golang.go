@@ -0,0 +1,238 @@
+package logo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The built-in commands in Go
+var goCommands = map[string]string{
+	"HOME":     "home()",
+	"PAPER":    "paper = %s",
+	"INK":      "ink = %s",
+	"PEN":      "pendown = %s",
+	"FORWARD":  "forward(%s)",
+	"BACK":     "back(%s)",
+	"LEFT":     "left(%s)",
+	"RIGHT":    "right(%s)",
+	"RERANDOM": "rerandom(%s)",
+	"PRINT":    "printValue(%s)",
+	"SHOW":     "showValue(%s)",
+	"TYPE":     "typeValue(%s)",
+}
+
+// The built-in reporters in Go
+var goReporters = map[string]string{
+	"RANDOM":   "random(%s)",
+	"PICK":     "pick(%s)",
+	"SIN":      "math.Sin(degToRad(%s))",
+	"COS":      "math.Cos(degToRad(%s))",
+	"SQRT":     "sqrt(%s)",
+	"ARCTAN":   "radToDeg(math.Atan(%s))",
+	"POWER":    "math.Pow(%s, %s)",
+	"ABS":      "math.Abs(%s)",
+	"INT":      "math.Trunc(%s)",
+	"ROUND":    "round(%s)",
+	"MODULO":   "modulo(%s, %s)",
+	"FIRST":    "first(%s)",
+	"LAST":     "last(%s)",
+	"BUTFIRST": "butFirst(%s)",
+	"BUTLAST":  "butLast(%s)",
+	"ITEM":     "item(%s, %s)",
+	"COUNT":    "length(%s)",
+	"FPUT":     "fput(%s, %s)",
+	"LPUT":     "lput(%s, %s)",
+	"SENTENCE": "sentence(%s, %s)",
+	"WORD":     "joinWords(%s, %s)",
+	"EMPTYP":   "boolWord(length(%s) == 0)",
+	"APPLY":    "applyList(%s, %s)",
+	"MAP":      "mapList(%s, %s)",
+	"FILTER":   "filterList(%s, %s)",
+	"REDUCE":   "reduceList(%s, %s)",
+}
+
+// GoBackend generates a Go main package drawing onto an image. The main
+// program is the function program, the procedures are functions taking and
+// outputting values. The errors are panics, but a CATCH block would have to
+// be a function literal to recover them, and OUTPUT and STOP in it would
+// return from the literal instead of the procedure. So there is no CATCH,
+// THROW or ERROR.
+type GoBackend struct{}
+
+func NewGoBackend() *GoBackend {
+	return &GoBackend{}
+}
+
+func (g *GoBackend) Name() string {
+	return "go"
+}
+
+func (g *GoBackend) Supports(keyword string) bool {
+	return keyword != "CATCH" && keyword != "THROW" && keyword != "ERROR"
+}
+
+func (g *GoBackend) Prologue() string {
+	return "func program() {"
+}
+
+func (g *GoBackend) Epilogue() string {
+	return "}"
+}
+
+func (g *GoBackend) Indent() string {
+	return "\t"
+}
+
+func (g *GoBackend) Empty() string {
+	return ""
+}
+
+func (g *GoBackend) Command(name string, args ...string) string {
+	return fill(goCommands, name, args)
+}
+
+func (g *GoBackend) Reporter(name string, args ...string) string {
+	return fill(goReporters, name, args)
+}
+
+func (g *GoBackend) Statement(call string) string {
+	return call
+}
+
+func (g *GoBackend) Line(line uint32) string {
+	return ""
+}
+
+func (g *GoBackend) Comment(text string) string {
+	return "// " + text
+}
+
+func (g *GoBackend) ProcedureBegin(proc *Procedure, name string, params []string) string {
+	for i, param := range params {
+		params[i] = param + " Value"
+	}
+
+	result := ""
+	if proc.Output {
+		result = "Value "
+	}
+	return fmt.Sprintf("func %s(%s) %s{", name, strings.Join(params, ", "), result)
+}
+
+func (g *GoBackend) ProcedureEnd(proc *Procedure) string {
+	return "}\n"
+}
+
+func (g *GoBackend) Output(value string) string {
+	return "return " + value
+}
+
+func (g *GoBackend) Stop(proc *Procedure) string {
+	if proc.Output {
+		return fmt.Sprintf("panic(fail(%q))", proc.Name+" did not output")
+	}
+	return "return"
+}
+
+func (g *GoBackend) RepeatBegin(variable, count string) string {
+	return fmt.Sprintf("for range int(%s) {", count)
+}
+
+func (g *GoBackend) ForeachBegin(variable, values string, depth int) string {
+	return fmt.Sprintf("for _, %s := range items(%s) {\n\tq := []Value{%s}\n\t_ = q", variable, values, variable)
+}
+
+func (g *GoBackend) ApplyBegin(values string, depth int) string {
+	return fmt.Sprintf("{\n\tq := list(%s)\n\t_ = q", values)
+}
+
+func (g *GoBackend) IfBegin(condition string) string {
+	return fmt.Sprintf("if truth(%s) {", condition)
+}
+
+func (g *GoBackend) Else() string {
+	return "} else {"
+}
+
+func (g *GoBackend) End() string {
+	return "}"
+}
+
+func (g *GoBackend) CatchBegin(tag string) string {
+	return ""
+}
+
+func (g *GoBackend) CatchEnd() string {
+	return ""
+}
+
+// Number makes the numbers floats, an integer constant would be an int value
+func (g *GoBackend) Number(value float64) string {
+	number := formatNumber(value)
+	if !strings.ContainsAny(number, ".eE") {
+		number += ".0"
+	}
+	return number
+}
+
+func (g *GoBackend) Word(value string) string {
+	return fmt.Sprintf("%q", value)
+}
+
+func (g *GoBackend) Boolean(value bool) string {
+	return fmt.Sprint(value)
+}
+
+func (g *GoBackend) List(items []string) string {
+	return "[]Value{" + strings.Join(items, ", ") + "}"
+}
+
+func (g *GoBackend) Template(expression string, depth int) string {
+	return fmt.Sprintf("func(q ...Value) Value { return %s }", expression)
+}
+
+func (g *GoBackend) Slot(index, depth int) string {
+	return fmt.Sprintf("slot(q, %d)", index)
+}
+
+func (g *GoBackend) Num(value string) string {
+	return fmt.Sprintf("num(%s)", value)
+}
+
+func (g *GoBackend) Color(value string) string {
+	return fmt.Sprintf("color(%s)", value)
+}
+
+func (g *GoBackend) Pen(value string) string {
+	return fmt.Sprintf("penState(%s)", value)
+}
+
+func (g *GoBackend) Count(value string) string {
+	return fmt.Sprintf("count(%s)", value)
+}
+
+func (g *GoBackend) Tag(value string) string {
+	return fmt.Sprintf("tag(%s)", value)
+}
+
+func (g *GoBackend) Arithmetic(left string, operator rune, right string) string {
+	if operator == '/' {
+		return fmt.Sprintf("divide(%s, %s)", left, right)
+	}
+	return fmt.Sprintf("(%s %c %s)", left, operator, right)
+}
+
+func (g *GoBackend) Negate(value string) string {
+	return fmt.Sprintf("(-%s)", value)
+}
+
+func (g *GoBackend) Compare(left string, operator rune, right string) string {
+	if operator == '=' {
+		return fmt.Sprintf("boolWord(equal(%s, %s))", left, right)
+	}
+	return fmt.Sprintf("boolWord(%s %c %s)", left, operator, right)
+}
+
+func (g *GoBackend) Call(name string, args []string) string {
+	return fmt.Sprintf("%s(%s)", name, strings.Join(args, ", "))
+}
@@ -0,0 +1,225 @@
+package logo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The built-in commands in JavaScript, the arguments fill the verbs in order
+var jsCommands = map[string]string{
+	"HOME":     "home();",
+	"PAPER":    "paper = %s;",
+	"INK":      "ink = %s;",
+	"PEN":      "pendown = %s;",
+	"FORWARD":  "forward(%s);",
+	"BACK":     "back(%s);",
+	"LEFT":     "left(%s);",
+	"RIGHT":    "right(%s);",
+	"RERANDOM": "rerandom(%s);",
+	"PRINT":    "printValue(%s);",
+	"SHOW":     "showValue(%s);",
+	"TYPE":     "typeValue(%s);",
+	"THROW":    "throwTag(%s);",
+}
+
+// The built-in reporters in JavaScript
+var jsReporters = map[string]string{
+	"RANDOM":   "random(%s)",
+	"PICK":     "pick(%s)",
+	"SIN":      "Math.sin(degToRad(%s))",
+	"COS":      "Math.cos(degToRad(%s))",
+	"SQRT":     "sqrt(%s)",
+	"ARCTAN":   "radToDeg(Math.atan(%s))",
+	"POWER":    "Math.pow(%s, %s)",
+	"ABS":      "Math.abs(%s)",
+	"INT":      "Math.trunc(%s)",
+	"ROUND":    "round(%s)",
+	"MODULO":   "modulo(%s, %s)",
+	"FIRST":    "first(%s)",
+	"LAST":     "last(%s)",
+	"BUTFIRST": "butFirst(%s)",
+	"BUTLAST":  "butLast(%s)",
+	"ITEM":     "item(%s, %s)",
+	"COUNT":    "items(%s).length",
+	"FPUT":     "[%s, ...list(%s)]",
+	"LPUT":     "[...list(%[2]s), %[1]s]",
+	"SENTENCE": "[%s, %s].flat()",
+	"WORD":     "(word(%s) + word(%s))",
+	"EMPTYP":   "boolWord(items(%s).length === 0)",
+	"ERROR":    "error()",
+	"APPLY":    "applyList(%s, %s)",
+	"MAP":      "mapList(%s, %s)",
+	"FILTER":   "filterList(%s, %s)",
+	"REDUCE":   "reduceList(%s, %s)",
+}
+
+// JSBackend generates JavaScript for the HTML5 canvas page of logo-compiler
+type JSBackend struct{}
+
+func NewJSBackend() *JSBackend {
+	return &JSBackend{}
+}
+
+func (js *JSBackend) Name() string {
+	return "js"
+}
+
+func (js *JSBackend) Supports(keyword string) bool {
+	return true
+}
+
+func (js *JSBackend) Prologue() string {
+	return ""
+}
+
+func (js *JSBackend) Epilogue() string {
+	return ""
+}
+
+func (js *JSBackend) Indent() string {
+	return "\t"
+}
+
+func (js *JSBackend) Empty() string {
+	return ""
+}
+
+func (js *JSBackend) Command(name string, args ...string) string {
+	return fill(jsCommands, name, args)
+}
+
+func (js *JSBackend) Reporter(name string, args ...string) string {
+	return fill(jsReporters, name, args)
+}
+
+func (js *JSBackend) Statement(call string) string {
+	return call + ";"
+}
+
+func (js *JSBackend) Line(line uint32) string {
+	return fmt.Sprintf("line = %d;", line)
+}
+
+// Comment escapes < like Word, a comment ends at </script> too
+func (js *JSBackend) Comment(text string) string {
+	return "// " + strings.ReplaceAll(text, "<", `\u003c`)
+}
+
+func (js *JSBackend) ProcedureBegin(proc *Procedure, name string, params []string) string {
+	return fmt.Sprintf("function %s(%s){", name, strings.Join(params, ","))
+}
+
+func (js *JSBackend) ProcedureEnd(proc *Procedure) string {
+	return "}"
+}
+
+func (js *JSBackend) Output(value string) string {
+	return fmt.Sprintf("return %s;", value)
+}
+
+func (js *JSBackend) Stop(proc *Procedure) string {
+	if proc.Output {
+		return fmt.Sprintf("throw new Error(%q);", proc.Name+" did not output")
+	}
+	return "return;"
+}
+
+func (js *JSBackend) RepeatBegin(variable, count string) string {
+	return fmt.Sprintf("for(let %s=0,%s_n=%s;%s<%s_n;++%s){", variable, variable, count, variable, variable, variable)
+}
+
+func (js *JSBackend) ForeachBegin(variable, values string, depth int) string {
+	return fmt.Sprintf("for(const %s of items(%s)){const q=[%s];", variable, values, variable)
+}
+
+func (js *JSBackend) ApplyBegin(values string, depth int) string {
+	return fmt.Sprintf("{const q=list(%s);", values)
+}
+
+func (js *JSBackend) IfBegin(condition string) string {
+	return fmt.Sprintf("if(truth(%s)){", condition)
+}
+
+func (js *JSBackend) Else() string {
+	return "}else{"
+}
+
+func (js *JSBackend) End() string {
+	return "}"
+}
+
+func (js *JSBackend) CatchBegin(tag string) string {
+	return fmt.Sprintf("catches.push(%s);try{", tag)
+}
+
+func (js *JSBackend) CatchEnd() string {
+	return "}catch(e){if(!caught(e)){throw e;}}finally{catches.pop();}"
+}
+
+func (js *JSBackend) Number(value float64) string {
+	return formatNumber(value)
+}
+
+// Word escapes < as well, the code goes into a <script> where </script>
+// would end it
+func (js *JSBackend) Word(value string) string {
+	return strings.ReplaceAll(fmt.Sprintf("%q", value), "<", `\u003c`)
+}
+
+func (js *JSBackend) Boolean(value bool) string {
+	return fmt.Sprint(value)
+}
+
+func (js *JSBackend) List(items []string) string {
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+func (js *JSBackend) Template(expression string, depth int) string {
+	return fmt.Sprintf("((...q) => %s)", expression)
+}
+
+func (js *JSBackend) Slot(index, depth int) string {
+	return fmt.Sprintf("q[%d]", index)
+}
+
+func (js *JSBackend) Num(value string) string {
+	return fmt.Sprintf("num(%s)", value)
+}
+
+func (js *JSBackend) Color(value string) string {
+	return fmt.Sprintf("color(%s)", value)
+}
+
+func (js *JSBackend) Pen(value string) string {
+	return fmt.Sprintf("penState(%s)", value)
+}
+
+func (js *JSBackend) Count(value string) string {
+	return fmt.Sprintf("count(%s)", value)
+}
+
+func (js *JSBackend) Tag(value string) string {
+	return fmt.Sprintf("tag(%s)", value)
+}
+
+func (js *JSBackend) Arithmetic(left string, operator rune, right string) string {
+	if operator == '/' {
+		return fmt.Sprintf("divide(%s, %s)", left, right)
+	}
+	return fmt.Sprintf("(%s %c %s)", left, operator, right)
+}
+
+func (js *JSBackend) Negate(value string) string {
+	return fmt.Sprintf("(-%s)", value)
+}
+
+func (js *JSBackend) Compare(left string, operator rune, right string) string {
+	if operator == '=' {
+		return fmt.Sprintf("boolWord(equal(%s, %s))", left, right)
+	}
+	return fmt.Sprintf("boolWord(%s %c %s)", left, operator, right)
+}
+
+func (js *JSBackend) Call(name string, args []string) string {
+	return fmt.Sprintf("%s(%s)", name, strings.Join(args, ", "))
+}
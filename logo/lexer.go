@@ -3,6 +3,7 @@ package logo
 import (
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 )
 
@@ -11,7 +12,7 @@ type Lexer struct {
 	Position      int
 	Line          uint32
 	String        string
-	Number        int
+	Number        float64
 	Literal       rune
 	Debug         bool
 	CommentSymbol rune
@@ -20,13 +21,17 @@ type Lexer struct {
 type Token int
 
 const (
-	TkEOF     Token = iota // 0 End of file
-	TkLiteral Token = iota // 1 Literal token
-	TkIdent   Token = iota // 2 Identifier
-	TkString  Token = iota // 3 String
-	TkNumber  Token = iota // 4 Number (decimal)
-	TkComment Token = iota // 5 Comment
-	TkEOL     Token = iota // 6 End of line
+	TkEOF       Token = iota // 0 End of file
+	TkLiteral   Token = iota // 1 Literal token
+	TkIdent     Token = iota // 2 Identifier
+	TkString    Token = iota // 3 String
+	TkNumber    Token = iota // 4 Number (decimal, with optional fraction)
+	TkComment   Token = iota // 5 Comment
+	TkEOL       Token = iota // 6 End of line
+	TkWord      Token = iota // 7 Quoted word ("abc)
+	TkListOpen  Token = iota // 8 Start of a list ([)
+	TkListClose Token = iota // 9 End of a list (])
+	TkValue     Token = iota // 10 Value filled in by a template, never lexed
 )
 
 func NewLexer(expr string) *Lexer {
@@ -61,7 +66,24 @@ func (l *Lexer) isEol() bool {
 }
 
 func (l *Lexer) isQuote() bool {
-	return !l.isEof() && (l.Expr[l.Position] == '"' || l.Expr[l.Position] == '\'')
+	return !l.isEof() && l.Expr[l.Position] == '\''
+}
+
+func (l *Lexer) isWordQuote() bool {
+	return !l.isEof() && l.Expr[l.Position] == '"'
+}
+
+// isWordEnd checks for the characters that terminate a quoted word
+func (l *Lexer) isWordEnd() bool {
+	if l.isEof() || l.isEol() || l.isWhiteSpace() {
+		return true
+	}
+
+	switch l.Expr[l.Position] {
+	case '[', ']', '(', ')':
+		return true
+	}
+	return false
 }
 
 // The map for literals is not complete, feel free to add yours
@@ -86,6 +108,7 @@ var LITERALS = map[rune]bool{
 	',': true,
 	'<': true,
 	'>': true,
+	'=': true,
 	'/': true,
 	'?': true,
 	'+': true,
@@ -105,6 +128,16 @@ func (l *Lexer) isNumeric() bool {
 	return !l.isEof() && (l.Expr[l.Position] >= '0' && l.Expr[l.Position] <= '9')
 }
 
+// isSlot checks for a numbered template slot like ?2
+func (l *Lexer) isSlot() bool {
+	return l.Position+1 < len(l.Expr) && l.Expr[l.Position] == '?' && l.Expr[l.Position+1] >= '0' && l.Expr[l.Position+1] <= '9'
+}
+
+// isFraction checks for a decimal point followed by a digit
+func (l *Lexer) isFraction() bool {
+	return l.Position+1 < len(l.Expr) && l.Expr[l.Position] == '.' && l.Expr[l.Position+1] >= '0' && l.Expr[l.Position+1] <= '9'
+}
+
 func (l *Lexer) isAlpha() bool {
 	return !l.isEof() && ((l.Expr[l.Position] >= 'A' && l.Expr[l.Position] <= 'Z') || (l.Expr[l.Position] >= 'a' && l.Expr[l.Position] <= 'z'))
 }
@@ -143,6 +176,30 @@ func (l *Lexer) NextToken() (Token, error) {
 		return TkComment, nil
 	}
 
+	if l.Expr[l.Position] == '[' || l.Expr[l.Position] == ']' {
+		l.dbg("List bracket at position %d", l.Position)
+
+		l.Literal = l.Expr[l.Position]
+		l.Position += 1
+		if l.Literal == '[' {
+			return TkListOpen, nil
+		}
+		return TkListClose, nil
+	}
+
+	if l.isSlot() {
+		l.dbg("Found numbered template slot at position %d", l.Position)
+
+		sb.WriteRune(l.Expr[l.Position])
+		l.Position += 1
+		for l.isNumeric() {
+			sb.WriteRune(l.Expr[l.Position])
+			l.Position += 1
+		}
+		l.String = sb.String()
+		return TkIdent, nil
+	}
+
 	if l.isLiteral() {
 		l.dbg("Literal 0x%02x at position %d", int(l.Expr[l.Position]), l.Position)
 
@@ -164,15 +221,30 @@ func (l *Lexer) NextToken() (Token, error) {
 		return TkString, nil
 	}
 
+	if l.isWordQuote() {
+		l.dbg("Found quoted word at position %d", l.Position)
+
+		l.Position += 1
+		for !l.isWordEnd() {
+			sb.WriteRune(l.Expr[l.Position])
+			l.Position += 1
+		}
+		l.String = sb.String()
+		return TkWord, nil
+	}
+
 	if l.isNumeric() {
 		l.dbg("Found number at position %d", l.Position)
 
-		number := 0
-		for l.isNumeric() {
-			number = number*10 + int(l.Expr[l.Position]-'0')
+		for l.isNumeric() || l.isFraction() {
+			sb.WriteRune(l.Expr[l.Position])
 			l.Position += 1
 		}
-		if !l.isWhiteSpace() && !l.isEol() && !l.isEof() {
+		if !l.isWhiteSpace() && !l.isEol() && !l.isEof() && !l.isLiteral() {
+			return TkEOF, fmt.Errorf("parse error at line %d", l.Line)
+		}
+		number, err := strconv.ParseFloat(sb.String(), 64)
+		if err != nil {
 			return TkEOF, fmt.Errorf("parse error at line %d", l.Line)
 		}
 		l.Number = number
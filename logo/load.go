@@ -0,0 +1,172 @@
+package logo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// LOAD and INCLUDE put the steps of another source file in their place
+// before the program runs or is compiled, the same way the procedures are
+// collected ahead. So the file name has to be a quoted word.
+
+// searchPath returns the directories of the LOGOPATH environment variable
+func searchPath() []string {
+	path := os.Getenv("LOGOPATH")
+	if path == "" {
+		return nil
+	}
+	return filepath.SplitList(path)
+}
+
+// parser builds the program steps of a source and the files it loads
+type parser struct {
+	path    []string        // directories searched after the one of the loading file
+	loading []string        // the files being loaded, innermost last
+	loaded  map[string]bool // every file is loaded once only
+}
+
+// parse builds the program steps of the source, with the loaded files in
+// place of LOAD and INCLUDE
+func parse(source string, path []string) ([]ProgramStep, error) {
+	p := &parser{path: path, loaded: map[string]bool{}}
+	return p.parse(source, "")
+}
+
+func (p *parser) parse(source, file string) ([]ProgramStep, error) {
+	l := NewLexer(source)
+	// l.Debug = true
+	program := []ProgramStep{}
+
+	for {
+		token, err := l.NextToken()
+		if err != nil {
+			return nil, fileError(file, err)
+		}
+
+		if token == TkEOF {
+			break
+		}
+
+		switch token {
+		case TkIdent:
+			program = append(program, ProgramStep{Token: token, String: l.String, Line: l.Line, File: file})
+		case TkNumber:
+			program = append(program, ProgramStep{Token: token, Number: l.Number, Line: l.Line, File: file})
+		case TkLiteral, TkListOpen, TkListClose:
+			program = append(program, ProgramStep{Token: token, Literal: l.Literal, Line: l.Line, File: file})
+		case TkWord, TkString:
+			program = append(program, ProgramStep{Token: token, String: l.String, Line: l.Line, File: file})
+		case TkEOL: // skipped
+		case TkComment: // skipped
+		default:
+			return nil, fileError(file, fmt.Errorf("invalid token %d in line %d", token, l.Line))
+		}
+	}
+
+	return p.load(program, file)
+}
+
+// load replaces LOAD and INCLUDE with the steps of the file. In a list they
+// are words like any other, the list is data.
+func (p *parser) load(program []ProgramStep, file string) ([]ProgramStep, error) {
+	steps := make([]ProgramStep, 0, len(program))
+	depth := 0 // nesting of the lists
+	for pc := 0; pc < len(program); pc++ {
+		switch program[pc].Token {
+		case TkListOpen:
+			depth += 1
+		case TkListClose:
+			depth -= 1
+		}
+
+		if depth > 0 || !isKeyword(program[pc], "LOAD") && !isKeyword(program[pc], "INCLUDE") {
+			steps = append(steps, program[pc])
+			continue
+		}
+
+		cmd := program[pc]
+		pc += 1
+		if pc == len(program) || program[pc].Token != TkWord {
+			return nil, fileError(file, fmt.Errorf("syntax error: %s needs a quoted file name in line %d", strings.ToUpper(cmd.String), cmd.Line))
+		}
+
+		included, err := p.include(program[pc].String, file, cmd.Line)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, included...)
+	}
+
+	return steps, nil
+}
+
+// include parses the named file, nothing if it is loaded already
+func (p *parser) include(name, from string, line uint32) ([]ProgramStep, error) {
+	file, key := p.find(name, from)
+	if file == "" {
+		return nil, fileError(from, fmt.Errorf("cannot find %s in line %d", name, line))
+	}
+
+	if slices.Contains(p.loading, key) {
+		return nil, fileError(from, fmt.Errorf("syntax error: %s loads itself in line %d", name, line))
+	}
+	if p.loaded[key] {
+		return nil, nil
+	}
+
+	source, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fileError(from, fmt.Errorf("cannot read %s in line %d: %w", name, line, err))
+	}
+
+	p.loaded[key] = true
+	p.loading = append(p.loading, key)
+	defer func() {
+		p.loading = p.loading[:len(p.loading)-1]
+	}()
+
+	return p.parse(string(source), file)
+}
+
+// find looks for the file next to the file loading it and then in the
+// search path, the .logo extension can be left out. It returns the file and
+// its absolute path, which tells the files apart.
+func (p *parser) find(name, from string) (file string, key string) {
+	names := []string{name}
+	if filepath.Ext(name) == "" {
+		names = append(names, name+".logo")
+	}
+
+	dirs := []string{""}
+	if !filepath.IsAbs(name) {
+		dirs = append([]string{filepath.Dir(from)}, p.path...)
+	}
+
+	for _, dir := range dirs {
+		for _, name := range names {
+			file := filepath.Join(dir, name)
+			if info, err := os.Stat(file); err != nil || info.IsDir() {
+				continue
+			}
+
+			key, err := filepath.Abs(file)
+			if err != nil {
+				key = file
+			}
+			return file, key
+		}
+	}
+
+	return "", ""
+}
+
+// fileError names the file the error is in, the main source has no name
+func fileError(file string, err error) error {
+	if file == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %w", file, err)
+}
@@ -0,0 +1,80 @@
+package logo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes the files to a new directory and returns it
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, source := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"shapes.logo": "to square :n repeat 4 forward :n right 90 loop print :n end",
+		"twice.logo":  "load \"shapes\nprint \"twice",
+	})
+
+	r := NewRuntime()
+	r.Path = []string{dir}
+	output, err := run(t, r, "load \"shapes\ninclude \"twice.logo\nload \"shapes\nsquare 10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// shapes is loaded once, twice loads it again for nothing
+	if output != "twice\n10\n" {
+		t.Fatalf("printed %q", output)
+	}
+}
+
+func TestLoadCycle(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.logo": "print \"a\nload \"b",
+		"b.logo": "print \"b\nload \"a",
+	})
+
+	r := NewRuntime()
+	r.Path = []string{dir}
+	output, err := run(t, r, "load \"a")
+	if output != "" {
+		t.Errorf("printed %q, a cycle is found before running", output)
+	}
+	if err == nil || !strings.Contains(err.Error(), "b.logo: syntax error: a loads itself in line 2") {
+		t.Fatalf("error %v", err)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	r := NewRuntime()
+	r.Path = []string{t.TempDir()}
+	_, err := run(t, r, "\nload \"nowhere")
+	if err == nil || !strings.Contains(err.Error(), "cannot find nowhere in line 2") {
+		t.Fatalf("error %v", err)
+	}
+}
+
+func TestLoadInListIsAWord(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"x.logo": "print \"loaded",
+	})
+
+	r := NewRuntime()
+	r.Path = []string{dir}
+	output, err := run(t, r, "show [load \"x] show [a [include \"x]]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != "[load \"x]\n[a [include \"x]]\n" {
+		t.Fatalf("printed %q", output)
+	}
+}
@@ -0,0 +1,35 @@
+package logo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Primitive describes a command defined outside of the package. The same
+// value can be registered on a Runtime and on a Compiler. Parameters declared
+// as TkNumber arrive as float64, parameters declared as TkIdent as string.
+type Primitive struct {
+	Name    string
+	Params  []Token                                // TkNumber or TkIdent for every parameter
+	Run     func(r *Runtime, args []Value) error   // runtime implementation
+	Compile func(c *Compiler, args []string) error // optional, args are expressions of c.Backend
+}
+
+func (p *Primitive) validate() error {
+	if p.Name == "" {
+		return errors.New("primitive without name")
+	}
+
+	for _, param := range p.Params {
+		if param != TkNumber && param != TkIdent {
+			return fmt.Errorf("primitive %s: unsupported parameter type %d", p.Name, param)
+		}
+	}
+
+	return nil
+}
+
+func (p *Primitive) keyword() string {
+	return strings.ToUpper(p.Name)
+}
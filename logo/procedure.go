@@ -0,0 +1,76 @@
+package logo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Procedure is a user defined procedure, TO <name> :<param>... <body> END
+type Procedure struct {
+	Name    string
+	Params  []string      // upper case names, without the colon
+	Program []ProgramStep // the program the procedure is defined in
+	Start   int           // first step of the body
+	End     int           // the END step
+	Output  bool          // the body has OUTPUT, so the procedure is a reporter
+	Line    uint32
+}
+
+// Frame holds the inputs of a running procedure
+type Frame struct {
+	Procedure *Procedure
+	Locals    map[string]Value
+	SP        int // loop stack pointer at the call
+}
+
+func isKeyword(step ProgramStep, keyword string) bool {
+	return step.Token == TkIdent && strings.ToUpper(step.String) == keyword
+}
+
+// scanProcedures collects the procedure definitions of the program, so they
+// can be called before the definition
+func scanProcedures(program []ProgramStep) (map[string]*Procedure, error) {
+	procedures := map[string]*Procedure{}
+
+	for pc := 0; pc < len(program); pc++ {
+		if !isKeyword(program[pc], "TO") {
+			continue
+		}
+
+		line, file := program[pc].Line, program[pc].File
+		pc += 1
+		if pc == len(program) || program[pc].Token != TkIdent {
+			return nil, fileError(file, fmt.Errorf("syntax error: missing procedure name in line %d", line))
+		}
+
+		proc := &Procedure{Name: strings.ToUpper(program[pc].String), Params: []string{}, Program: program, Line: line}
+		if _, ok := procedures[proc.Name]; ok {
+			return nil, fileError(file, fmt.Errorf("syntax error: %s is already defined in line %d", proc.Name, line))
+		}
+		pc += 1
+
+		for pc+1 < len(program) && program[pc].Token == TkLiteral && program[pc].Literal == ':' && program[pc+1].Token == TkIdent {
+			proc.Params = append(proc.Params, strings.ToUpper(program[pc+1].String))
+			pc += 2
+		}
+
+		proc.Start = pc
+		for ; pc < len(program) && !isKeyword(program[pc], "END"); pc++ {
+			if isKeyword(program[pc], "TO") {
+				return nil, fileError(file, fmt.Errorf("syntax error: nested TO in line %d", program[pc].Line))
+			}
+			if isKeyword(program[pc], "OUTPUT") {
+				proc.Output = true
+			}
+		}
+
+		if pc == len(program) {
+			return nil, fileError(file, fmt.Errorf("syntax error: missing END of %s defined in line %d", proc.Name, line))
+		}
+
+		proc.End = pc
+		procedures[proc.Name] = proc
+	}
+
+	return procedures, nil
+}
@@ -0,0 +1,231 @@
+package logo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The built-in commands in Python, the turtle draws with its own heading
+var pyCommands = map[string]string{
+	"HOME":     "home()",
+	"PAPER":    "screen.bgcolor(%s)",
+	"INK":      "pen.pencolor(%s)",
+	"PEN":      "setPen(%s)",
+	"FORWARD":  "pen.forward(%s)",
+	"BACK":     "pen.back(%s)",
+	"LEFT":     "pen.left(%s)",
+	"RIGHT":    "pen.right(%s)",
+	"RERANDOM": "rerandom(%s)",
+	"PRINT":    "printValue(%s)",
+	"SHOW":     "showValue(%s)",
+	"TYPE":     "typeValue(%s)",
+	"THROW":    "throwTag(%s)",
+}
+
+// The built-in reporters in Python
+var pyReporters = map[string]string{
+	"RANDOM":   "random(%s)",
+	"PICK":     "pick(%s)",
+	"SIN":      "math.sin(degToRad(%s))",
+	"COS":      "math.cos(degToRad(%s))",
+	"SQRT":     "sqrt(%s)",
+	"ARCTAN":   "radToDeg(math.atan(%s))",
+	"POWER":    "math.pow(%s, %s)",
+	"ABS":      "abs(%s)",
+	"INT":      "math.trunc(%s)",
+	"ROUND":    "round(%s)",
+	"MODULO":   "modulo(%s, %s)",
+	"FIRST":    "first(%s)",
+	"LAST":     "last(%s)",
+	"BUTFIRST": "butFirst(%s)",
+	"BUTLAST":  "butLast(%s)",
+	"ITEM":     "item(%s, %s)",
+	"COUNT":    "len(items(%s))",
+	"FPUT":     "[%s, *list(%s)]",
+	"LPUT":     "[*list(%[2]s), %[1]s]",
+	"SENTENCE": "sentence(%s, %s)",
+	"WORD":     "joinWords(%s, %s)",
+	"EMPTYP":   "boolWord(len(items(%s)) == 0)",
+	"ERROR":    "error()",
+	"APPLY":    "applyList(%s, %s)",
+	"MAP":      "mapList(%s, %s)",
+	"FILTER":   "filterList(%s, %s)",
+	"REDUCE":   "reduceList(%s, %s)",
+}
+
+// PythonBackend generates a Python program drawing with the turtle module.
+// The main program is the function program, the procedures are functions
+// and the templates lambdas. Python has no block scope, so the inputs of the
+// templates are named by their depth.
+type PythonBackend struct{}
+
+func NewPythonBackend() *PythonBackend {
+	return &PythonBackend{}
+}
+
+func (py *PythonBackend) Name() string {
+	return "python"
+}
+
+func (py *PythonBackend) Supports(keyword string) bool {
+	return true
+}
+
+func (py *PythonBackend) Prologue() string {
+	return "def program():"
+}
+
+func (py *PythonBackend) Epilogue() string {
+	return ""
+}
+
+func (py *PythonBackend) Indent() string {
+	return "    "
+}
+
+func (py *PythonBackend) Empty() string {
+	return "pass"
+}
+
+func (py *PythonBackend) Command(name string, args ...string) string {
+	return fill(pyCommands, name, args)
+}
+
+func (py *PythonBackend) Reporter(name string, args ...string) string {
+	return fill(pyReporters, name, args)
+}
+
+func (py *PythonBackend) Statement(call string) string {
+	return call
+}
+
+func (py *PythonBackend) Line(line uint32) string {
+	return fmt.Sprintf("setLine(%d)", line)
+}
+
+func (py *PythonBackend) Comment(text string) string {
+	return "# " + text
+}
+
+func (py *PythonBackend) ProcedureBegin(proc *Procedure, name string, params []string) string {
+	return fmt.Sprintf("def %s(%s):", name, strings.Join(params, ", "))
+}
+
+// ProcedureEnd leaves two blank lines after the function
+func (py *PythonBackend) ProcedureEnd(proc *Procedure) string {
+	return "\n"
+}
+
+func (py *PythonBackend) Output(value string) string {
+	return "return " + value
+}
+
+func (py *PythonBackend) Stop(proc *Procedure) string {
+	if proc.Output {
+		return fmt.Sprintf("raise Exception(%q)", proc.Name+" did not output")
+	}
+	return "return"
+}
+
+func (py *PythonBackend) RepeatBegin(variable, count string) string {
+	return fmt.Sprintf("for %s in range(int(%s)):", variable, count)
+}
+
+func (py *PythonBackend) ForeachBegin(variable, values string, depth int) string {
+	return fmt.Sprintf("for %s in items(%s):\n    q%d = [%s]", variable, values, depth, variable)
+}
+
+// ApplyBegin loops over the single list of inputs, a block where the inputs
+// have a name
+func (py *PythonBackend) ApplyBegin(values string, depth int) string {
+	return fmt.Sprintf("for q%d in [list(%s)]:", depth, values)
+}
+
+func (py *PythonBackend) IfBegin(condition string) string {
+	return fmt.Sprintf("if truth(%s):", condition)
+}
+
+func (py *PythonBackend) Else() string {
+	return "else:"
+}
+
+func (py *PythonBackend) End() string {
+	return ""
+}
+
+func (py *PythonBackend) CatchBegin(tag string) string {
+	return fmt.Sprintf("catches.append(%s)\ntry:", tag)
+}
+
+func (py *PythonBackend) CatchEnd() string {
+	return "except Exception as e:\n    if not caught(e):\n        raise\nfinally:\n    catches.pop()"
+}
+
+func (py *PythonBackend) Number(value float64) string {
+	return formatNumber(value)
+}
+
+func (py *PythonBackend) Word(value string) string {
+	return fmt.Sprintf("%q", value)
+}
+
+func (py *PythonBackend) Boolean(value bool) string {
+	if value {
+		return "True"
+	}
+	return "False"
+}
+
+func (py *PythonBackend) List(items []string) string {
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+func (py *PythonBackend) Template(expression string, depth int) string {
+	return fmt.Sprintf("(lambda *q%d: %s)", depth, expression)
+}
+
+func (py *PythonBackend) Slot(index, depth int) string {
+	return fmt.Sprintf("slot(q%d, %d)", depth, index)
+}
+
+func (py *PythonBackend) Num(value string) string {
+	return fmt.Sprintf("num(%s)", value)
+}
+
+func (py *PythonBackend) Color(value string) string {
+	return fmt.Sprintf("color(%s)", value)
+}
+
+func (py *PythonBackend) Pen(value string) string {
+	return fmt.Sprintf("penState(%s)", value)
+}
+
+func (py *PythonBackend) Count(value string) string {
+	return fmt.Sprintf("count(%s)", value)
+}
+
+func (py *PythonBackend) Tag(value string) string {
+	return fmt.Sprintf("tag(%s)", value)
+}
+
+func (py *PythonBackend) Arithmetic(left string, operator rune, right string) string {
+	if operator == '/' {
+		return fmt.Sprintf("divide(%s, %s)", left, right)
+	}
+	return fmt.Sprintf("(%s %c %s)", left, operator, right)
+}
+
+func (py *PythonBackend) Negate(value string) string {
+	return fmt.Sprintf("(-%s)", value)
+}
+
+func (py *PythonBackend) Compare(left string, operator rune, right string) string {
+	if operator == '=' {
+		return fmt.Sprintf("boolWord(equal(%s, %s))", left, right)
+	}
+	return fmt.Sprintf("boolWord(%s %c %s)", left, operator, right)
+}
+
+func (py *PythonBackend) Call(name string, args []string) string {
+	return fmt.Sprintf("%s(%s)", name, strings.Join(args, ", "))
+}
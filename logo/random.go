@@ -0,0 +1,42 @@
+package logo
+
+// RandomSource produces the numbers behind RANDOM and PICK. *rand.Rand from
+// math/rand satisfies it as well.
+type RandomSource interface {
+	Intn(n int) int
+	Seed(seed int64)
+}
+
+// Mulberry32 is a small 32 bit generator. The compiled JavaScript uses the
+// very same algorithm, so the interpreter and the browser draw the same
+// picture for the same seed.
+type Mulberry32 struct {
+	state uint32
+}
+
+func NewMulberry32(seed int64) *Mulberry32 {
+	m := &Mulberry32{}
+	m.Seed(seed)
+	return m
+}
+
+func (m *Mulberry32) Seed(seed int64) {
+	m.state = uint32(seed)
+}
+
+func (m *Mulberry32) Uint32() uint32 {
+	m.state += 0x6d2b79f5
+	t := m.state
+	t = (t ^ t>>15) * (t | 1)
+	t ^= t + (t^t>>7)*(t|61)
+	return t ^ t>>14
+}
+
+// Intn returns a number in [0,n), n has to be below 2^21 to match the
+// JavaScript implementation.
+func (m *Mulberry32) Intn(n int) int {
+	if n <= 0 {
+		panic("invalid argument to Intn")
+	}
+	return int(uint64(m.Uint32()) * uint64(n) >> 32)
+}
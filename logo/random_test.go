@@ -0,0 +1,73 @@
+package logo
+
+import (
+	"bytes"
+	"testing"
+)
+
+// run runs the program on the runtime and returns what it printed
+func run(t *testing.T, r *Runtime, program string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	r.Writer = &out
+	err := r.Run(program)
+	return out.String(), err
+}
+
+func TestMulberry32Sequence(t *testing.T) {
+	// The first numbers of the reference mulberry32 for the seed 42
+	expected := []uint32{2581720956, 1925393290, 3661312704, 2876485805, 750819978}
+
+	m := NewMulberry32(42)
+	for i, want := range expected {
+		if got := m.Uint32(); got != want {
+			t.Fatalf("number %d: got %d, want %d", i, got, want)
+		}
+	}
+
+	m.Seed(42)
+	if got := m.Uint32(); got != expected[0] {
+		t.Fatalf("after Seed: got %d, want %d", got, expected[0])
+	}
+}
+
+func TestMulberry32Intn(t *testing.T) {
+	m := NewMulberry32(7)
+	for range 1000 {
+		if n := m.Intn(10); n < 0 || n >= 10 {
+			t.Fatalf("Intn(10) = %d", n)
+		}
+	}
+}
+
+func TestSeededRunIsReproducible(t *testing.T) {
+	program := `
+repeat 5 print random 1000 loop
+print pick [red green blue yellow]
+print pick [1 2 3 4 5 6 7 8 9]
+`
+	r := NewRuntime()
+	r.Random.Seed(5)
+	first, err := run(t, r, program)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Random.Seed(5)
+	second, err := run(t, r, program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Fatalf("the same seed printed\n%s\nand\n%s", first, second)
+	}
+
+	// RERANDOM in the program does the same
+	third, err := run(t, NewRuntime(), "rerandom 5"+program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third != first {
+		t.Fatalf("RERANDOM 5 printed\n%s\nSeed(5) printed\n%s", third, first)
+	}
+}
@@ -3,13 +3,18 @@ package logo
 import (
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"math"
+	"os"
+	"runtime"
 	"strings"
+	"time"
 )
 
 type Color int
 type Command func(r *Runtime)
+type Reporter func(r *Runtime) Value
 
 type DrawingStub interface {
 	Clear(r *Runtime)
@@ -35,20 +40,37 @@ type ProgramStep struct {
 	Token   Token
 	Line    uint32
 	String  string
-	Number  int
+	Number  float64
 	Literal rune
+	Value   Value  // the input of a template slot (TkValue)
+	File    string // the loaded file of the step, empty for the main source
 }
 
 type Runtime struct {
-	Program []ProgramStep
-	Stub    DrawingStub
-	PC      int
-	Stack   [256]int // this allow 128 nested loops
-	SP      int
-	Trace   bool
+	Program    []ProgramStep
+	Keywords   map[string]Command
+	Reporters  map[string]Reporter
+	Procedures map[string]*Procedure             // the workspace, it stays from one Run to the next
+	defined    map[string]*Procedure             // the procedures of the running program
+	Editor     func(text string) (string, error) // EDIT changes the text with it
+	Frames     []Frame
+	slots      [][]Value // inputs of the running templates, for ? and ?<n>
+	catches    []catch   // the running CATCHes, innermost last
+	failure    []Value   // the last error caught by CATCH "ERROR, for ERROR
+	failed     uint32    // the line of the last runtime error
+	failedFile string    // and its file
+	Path       []string  // directories LOAD and INCLUDE search, LOGOPATH by default
+	Stub       DrawingStub
+	Writer     io.Writer // PRINT, SHOW and TYPE write here
+	Random     RandomSource
+	PC         int
+	Stack      []int // two entries for every running loop, grows as needed
+	SP         int
+	MaxDepth   int // the limit of nested loops and procedure calls together
+	Trace      bool
 
 	Head    Position
-	Angle   int
+	Angle   float64
 	PenDown bool
 	Paper   Color
 	Ink     Color
@@ -76,17 +98,77 @@ var COLORS = map[string]Color{
 	"MAGENTA": Magenta,
 }
 
+// KEYWORDS are the built-in commands, every new Runtime starts with a copy
 var KEYWORDS = map[string]Command{
-	"HOME":    homeCmd,
-	"PAPER":   paperCmd,
-	"INK":     inkCmd,
-	"PEN":     penCmd,
-	"REPEAT":  repeatCmd,
-	"LOOP":    loopCmd,
-	"FORWARD": forwardCmd,
-	"BACK":    backCmd,
-	"LEFT":    leftCmd,
-	"RIGHT":   rightCmd,
+	"HOME":     homeCmd,
+	"PAPER":    paperCmd,
+	"INK":      inkCmd,
+	"PEN":      penCmd,
+	"REPEAT":   repeatCmd,
+	"LOOP":     loopCmd,
+	"FORWARD":  forwardCmd,
+	"FD":       forwardCmd,
+	"BACK":     backCmd,
+	"BK":       backCmd,
+	"LEFT":     leftCmd,
+	"LT":       leftCmd,
+	"RIGHT":    rightCmd,
+	"RT":       rightCmd,
+	"RERANDOM": rerandomCmd,
+	"TO":       toCmd,
+	"OUTPUT":   outputCmd,
+	"STOP":     stopCmd,
+	"IF":       ifCmd,
+	"IFELSE":   ifElseCmd,
+	"PRINT":    printCmd,
+	"PR":       printCmd,
+	"SHOW":     showCmd,
+	"TYPE":     typeCmd,
+	"RUN":      runCmd,
+	"APPLY":    applyCmd,
+	"FOREACH":  foreachCmd,
+	"CATCH":    catchCmd,
+	"THROW":    throwCmd,
+	"PO":       poCmd,
+	"POTS":     potsCmd,
+	"ERASE":    eraseCmd,
+	"SAVE":     saveCmd,
+	"EDIT":     editCmd,
+}
+
+// REPORTERS are the built-in operations that output a value, they can be used
+// in place of any parameter
+var REPORTERS = map[string]Reporter{
+	"RANDOM":   randomReporter,
+	"PICK":     pickReporter,
+	"SIN":      sinReporter,
+	"COS":      cosReporter,
+	"SQRT":     sqrtReporter,
+	"ARCTAN":   arctanReporter,
+	"POWER":    powerReporter,
+	"ABS":      absReporter,
+	"INT":      intReporter,
+	"ROUND":    roundReporter,
+	"MODULO":   moduloReporter,
+	"FIRST":    firstReporter,
+	"LAST":     lastReporter,
+	"BUTFIRST": butFirstReporter,
+	"BF":       butFirstReporter,
+	"BUTLAST":  butLastReporter,
+	"BL":       butLastReporter,
+	"ITEM":     itemReporter,
+	"COUNT":    countReporter,
+	"FPUT":     fputReporter,
+	"LPUT":     lputReporter,
+	"SENTENCE": sentenceReporter,
+	"SE":       sentenceReporter,
+	"WORD":     wordReporter,
+	"EMPTYP":   emptypReporter,
+	"APPLY":    applyReporter,
+	"MAP":      mapReporter,
+	"FILTER":   filterReporter,
+	"REDUCE":   reduceReporter,
+	"ERROR":    errorReporter,
 }
 
 func homeCmd(r *Runtime) {
@@ -107,21 +189,20 @@ func inkCmd(r *Runtime) {
 
 func penCmd(r *Runtime) {
 	r.trace("PEN")
-	token := r.getParam(TkIdent)
-	value := strings.ToUpper(token.String)
+	value := strings.ToUpper(r.getWord())
 	if value == "UP" || value == "DOWN" {
 		r.PenDown = value == "DOWN"
 		return
 	}
 
-	r.syntaxError(fmt.Sprintf("invalid parameter in line %d", token.Line))
+	r.syntaxError(fmt.Sprintf("invalid parameter in line %d", r.line()))
 }
 
 func forwardCmd(r *Runtime) {
 	r.trace("FORWARD")
-	step := r.getParam(TkNumber).Number
-	dx := float64(step) * math.Cos(r.DegToRad(r.Angle))
-	dy := float64(step) * math.Sin(r.DegToRad(r.Angle))
+	step := r.getNumber()
+	dx := step * math.Cos(r.DegToRad(r.Angle))
+	dy := step * math.Sin(r.DegToRad(r.Angle))
 
 	if r.PenDown {
 		r.Stub.DrawLine(r, int32(r.Head.X), int32(r.Head.Y), int32(r.Head.X+dx), int32(r.Head.Y+dy))
@@ -133,9 +214,9 @@ func forwardCmd(r *Runtime) {
 
 func backCmd(r *Runtime) {
 	r.trace("BACK")
-	step := r.getParam(TkNumber).Number
-	dx := float64(step) * math.Cos(r.DegToRad(r.Angle))
-	dy := float64(step) * math.Sin(r.DegToRad(r.Angle))
+	step := r.getNumber()
+	dx := step * math.Cos(r.DegToRad(r.Angle))
+	dy := step * math.Sin(r.DegToRad(r.Angle))
 
 	if r.PenDown {
 		r.Stub.DrawLine(r, int32(r.Head.X), int32(r.Head.Y), int32(r.Head.X-dx), int32(r.Head.Y-dy))
@@ -147,23 +228,24 @@ func backCmd(r *Runtime) {
 
 func leftCmd(r *Runtime) {
 	r.trace("LEFT")
-	r.Angle = (r.Angle + r.getParam(TkNumber).Number) % 360
+	r.Angle = math.Mod(r.Angle+r.getNumber(), 360)
 }
 
 func rightCmd(r *Runtime) {
 	r.trace("RIGHT")
-	r.Angle = (r.Angle - r.getParam(TkNumber).Number) % 360
+	r.Angle = math.Mod(r.Angle-r.getNumber(), 360)
 }
 
 func repeatCmd(r *Runtime) {
 	r.trace("REPEAT")
-	token := r.getParam(TkNumber)
-	if token.Number <= 0 || token.Number >= 65536 {
-		r.syntaxError(fmt.Sprintf("the count is too small or too large number in line %d", token.Line))
+	count := int(r.getNumber())
+	if count <= 0 || count >= 65536 {
+		r.syntaxError(fmt.Sprintf("the count is too small or too large number in line %d", r.line()))
 	}
 
-	r.push(r.PC)         // save pc
-	r.push(token.Number) // save counter
+	r.checkDepth()
+	r.push(r.PC)  // save pc
+	r.push(count) // save counter
 }
 
 func loopCmd(r *Runtime) {
@@ -180,6 +262,393 @@ func loopCmd(r *Runtime) {
 	}
 }
 
+// toCmd skips the definition, procedures are collected before running. The
+// definition is put back to the workspace, in case it was erased meanwhile.
+func toCmd(r *Runtime) {
+	r.trace("TO")
+	name := r.getParam(TkIdent)
+	proc, ok := r.defined[strings.ToUpper(name.String)]
+	if r.SP != 0 || len(r.Frames) != 0 || !ok || !sameProgram(proc.Program, r.Program) {
+		r.syntaxError(fmt.Sprintf("TO is only allowed at the top level in line %d", name.Line))
+	}
+
+	r.Procedures[proc.Name] = proc
+	r.PC = proc.End + 1
+}
+
+// procedureExit unwinds the running procedure on OUTPUT and STOP
+type procedureExit struct {
+	value Value
+}
+
+func outputCmd(r *Runtime) {
+	r.trace("OUTPUT")
+	if len(r.Frames) == 0 {
+		r.syntaxError(fmt.Sprintf("OUTPUT outside of a procedure in line %d", r.line()))
+	}
+	panic(procedureExit{value: r.evaluate()})
+}
+
+func stopCmd(r *Runtime) {
+	r.trace("STOP")
+	if len(r.Frames) == 0 {
+		r.syntaxError(fmt.Sprintf("STOP outside of a procedure in line %d", r.line()))
+	}
+	panic(procedureExit{})
+}
+
+// catch is a running CATCH
+type catch struct {
+	tag    string
+	frames int // the procedure it runs in, calls there are not tail calls
+}
+
+// thrown unwinds to the CATCH of the tag
+type thrown struct {
+	tag string
+}
+
+func catchCmd(r *Runtime) {
+	r.trace("CATCH")
+	tag := strings.ToUpper(r.getWord())
+	steps, err := listSteps(r.getList(), r.line())
+	if err != nil {
+		r.runtimeError(err)
+	}
+	r.catch(tag, func() {
+		r.instructions(steps)
+	})
+}
+
+func throwCmd(r *Runtime) {
+	r.trace("THROW")
+	tag := strings.ToUpper(r.getWord())
+	for _, running := range r.catches {
+		if running.tag == tag {
+			panic(thrown{tag: tag})
+		}
+	}
+	r.syntaxError(fmt.Sprintf("no CATCH for %s in line %d", tag, r.line()))
+}
+
+func ifCmd(r *Runtime) {
+	r.trace("IF")
+	if r.truth(r.evaluate()) {
+		r.block()
+	} else {
+		r.skipBlock()
+	}
+}
+
+func ifElseCmd(r *Runtime) {
+	r.trace("IFELSE")
+	if r.truth(r.evaluate()) {
+		r.block()
+		r.skipBlock()
+	} else {
+		r.skipBlock()
+		r.block()
+	}
+}
+
+func printCmd(r *Runtime) {
+	r.trace("PRINT")
+	r.write(FormatValue(r.evaluate()) + "\n")
+}
+
+// showCmd prints lists with their brackets
+func showCmd(r *Runtime) {
+	r.trace("SHOW")
+	value := r.evaluate()
+	if _, ok := value.([]Value); ok {
+		r.write("[" + FormatValue(value) + "]\n")
+	} else {
+		r.write(FormatValue(value) + "\n")
+	}
+}
+
+// typeCmd prints without a line break
+func typeCmd(r *Runtime) {
+	r.trace("TYPE")
+	r.write(FormatValue(r.evaluate()))
+}
+
+func (r *Runtime) write(text string) {
+	if _, err := io.WriteString(r.Writer, text); err != nil {
+		r.runtimeError(err)
+	}
+}
+
+func runCmd(r *Runtime) {
+	r.trace("RUN")
+	steps, err := listSteps(r.getList(), r.line())
+	if err != nil {
+		r.runtimeError(err)
+	}
+	r.instructions(steps)
+}
+
+func applyCmd(r *Runtime) {
+	r.trace("APPLY")
+	template := r.evaluate()
+	inputs := r.getList()
+	r.instructions(r.template(template, inputs), inputs...)
+}
+
+func foreachCmd(r *Runtime) {
+	r.trace("FOREACH")
+	items := r.items(r.evaluate())
+	template := r.evaluate()
+	for _, item := range items {
+		r.instructions(r.template(template, []Value{item}), item)
+	}
+}
+
+func rerandomCmd(r *Runtime) {
+	r.trace("RERANDOM")
+	r.Random.Seed(int64(r.getNumber()))
+}
+
+func randomReporter(r *Runtime) Value {
+	r.trace("RANDOM")
+	limit := int(r.getNumber())
+	if limit <= 0 {
+		r.syntaxError(fmt.Sprintf("the limit of random must be positive in line %d", r.line()))
+	}
+
+	return float64(r.Random.Intn(limit))
+}
+
+func pickReporter(r *Runtime) Value {
+	r.trace("PICK")
+	items := r.getList()
+	if len(items) == 0 {
+		r.syntaxError(fmt.Sprintf("cannot pick from an empty list in line %d", r.line()))
+	}
+
+	return items[r.Random.Intn(len(items))]
+}
+
+func sinReporter(r *Runtime) Value {
+	r.trace("SIN")
+	return math.Sin(r.DegToRad(r.getNumber()))
+}
+
+func cosReporter(r *Runtime) Value {
+	r.trace("COS")
+	return math.Cos(r.DegToRad(r.getNumber()))
+}
+
+func sqrtReporter(r *Runtime) Value {
+	r.trace("SQRT")
+	value := r.getNumber()
+	if value < 0 {
+		r.syntaxError(fmt.Sprintf("square root of a negative number in line %d", r.line()))
+	}
+	return math.Sqrt(value)
+}
+
+func arctanReporter(r *Runtime) Value {
+	r.trace("ARCTAN")
+	return r.RadToDeg(math.Atan(r.getNumber()))
+}
+
+func powerReporter(r *Runtime) Value {
+	r.trace("POWER")
+	base := r.getNumber()
+	return math.Pow(base, r.getNumber())
+}
+
+func absReporter(r *Runtime) Value {
+	r.trace("ABS")
+	return math.Abs(r.getNumber())
+}
+
+func intReporter(r *Runtime) Value {
+	r.trace("INT")
+	return math.Trunc(r.getNumber())
+}
+
+func roundReporter(r *Runtime) Value {
+	r.trace("ROUND")
+	return math.Round(r.getNumber())
+}
+
+// moduloReporter outputs the remainder with the sign of the divisor
+func moduloReporter(r *Runtime) Value {
+	r.trace("MODULO")
+	a := r.getNumber()
+	b := r.getNumber()
+	if b == 0 {
+		r.syntaxError(fmt.Sprintf("division by zero in line %d", r.line()))
+	}
+	return a - b*math.Floor(a/b)
+}
+
+func firstReporter(r *Runtime) Value {
+	r.trace("FIRST")
+	return r.nonEmpty(r.evaluate(), "FIRST")[0]
+}
+
+func lastReporter(r *Runtime) Value {
+	r.trace("LAST")
+	items := r.nonEmpty(r.evaluate(), "LAST")
+	return items[len(items)-1]
+}
+
+func butFirstReporter(r *Runtime) Value {
+	r.trace("BUTFIRST")
+	value := r.evaluate()
+	return r.rebuild(value, r.nonEmpty(value, "BUTFIRST")[1:])
+}
+
+func butLastReporter(r *Runtime) Value {
+	r.trace("BUTLAST")
+	value := r.evaluate()
+	items := r.nonEmpty(value, "BUTLAST")
+	return r.rebuild(value, items[:len(items)-1])
+}
+
+func itemReporter(r *Runtime) Value {
+	r.trace("ITEM")
+	index := int(r.getNumber())
+	items := r.items(r.evaluate())
+	if index < 1 || index > len(items) {
+		r.syntaxError(fmt.Sprintf("ITEM %d is out of range in line %d", index, r.line()))
+	}
+	return items[index-1]
+}
+
+func countReporter(r *Runtime) Value {
+	r.trace("COUNT")
+	return float64(len(r.items(r.evaluate())))
+}
+
+func fputReporter(r *Runtime) Value {
+	r.trace("FPUT")
+	item := r.evaluate()
+	return append([]Value{item}, r.getList()...)
+}
+
+func lputReporter(r *Runtime) Value {
+	r.trace("LPUT")
+	item := r.evaluate()
+	list := r.getList()
+	return append(list[:len(list):len(list)], item)
+}
+
+func sentenceReporter(r *Runtime) Value {
+	r.trace("SENTENCE")
+	sentence := []Value{}
+	for i := 0; i < 2; i++ {
+		value := r.evaluate()
+		if list, ok := value.([]Value); ok {
+			sentence = append(sentence, list...)
+		} else {
+			sentence = append(sentence, value)
+		}
+	}
+	return sentence
+}
+
+func wordReporter(r *Runtime) Value {
+	r.trace("WORD")
+	first := r.word(r.evaluate())
+	return first + r.word(r.evaluate())
+}
+
+// errorReporter outputs the message and the line of the error caught last,
+// once, an empty list if there is none
+func errorReporter(r *Runtime) Value {
+	r.trace("ERROR")
+	failure := r.failure
+	r.failure = nil
+	if failure == nil {
+		return []Value{}
+	}
+	return failure
+}
+
+func emptypReporter(r *Runtime) Value {
+	r.trace("EMPTYP")
+	return boolWord(len(r.items(r.evaluate())) == 0)
+}
+
+func applyReporter(r *Runtime) Value {
+	r.trace("APPLY")
+	template := r.evaluate()
+	return r.apply(template, r.getList()...)
+}
+
+func mapReporter(r *Runtime) Value {
+	r.trace("MAP")
+	template := r.evaluate()
+	mapped := []Value{}
+	for _, item := range r.items(r.evaluate()) {
+		mapped = append(mapped, r.apply(template, item))
+	}
+	return mapped
+}
+
+func filterReporter(r *Runtime) Value {
+	r.trace("FILTER")
+	template := r.evaluate()
+	filtered := []Value{}
+	for _, item := range r.items(r.evaluate()) {
+		if r.truth(r.apply(template, item)) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// reduceReporter combines the items from the right, (reduce "word [a b c])
+// is (word "a (word "b "c))
+func reduceReporter(r *Runtime) Value {
+	r.trace("REDUCE")
+	template := r.evaluate()
+	items := r.nonEmpty(r.evaluate(), "REDUCE")
+	value := items[len(items)-1]
+	for i := len(items) - 2; i >= 0; i-- {
+		value = r.apply(template, items[i], value)
+	}
+	return value
+}
+
+// items splits a list to its items and a word to its characters
+func (r *Runtime) items(value Value) []Value {
+	if list, ok := value.([]Value); ok {
+		return list
+	}
+
+	chars := []Value{}
+	for _, ch := range r.word(value) {
+		chars = append(chars, string(ch))
+	}
+	return chars
+}
+
+func (r *Runtime) nonEmpty(value Value, name string) []Value {
+	items := r.items(value)
+	if len(items) == 0 {
+		r.syntaxError(fmt.Sprintf("%s of an empty word or list in line %d", name, r.line()))
+	}
+	return items
+}
+
+// rebuild makes a word or a list from the items, matching the original value
+func (r *Runtime) rebuild(original Value, items []Value) Value {
+	if _, ok := original.([]Value); ok {
+		return items
+	}
+
+	var sb strings.Builder
+	for _, item := range items {
+		sb.WriteString(item.(string))
+	}
+	return sb.String()
+}
+
 func (r *Runtime) trace(msg string) {
 	if r.Trace {
 		log.Printf("TRACE: %s\n", msg)
@@ -187,6 +656,7 @@ func (r *Runtime) trace(msg string) {
 }
 
 func (r *Runtime) runtimeError(err error) {
+	r.failed, r.failedFile = r.line(), r.file()
 	panic(err)
 }
 
@@ -195,12 +665,11 @@ func (r *Runtime) syntaxError(err string) {
 }
 
 func (r *Runtime) getColor() Color {
-	token := r.getParam(TkIdent)
-	color := strings.ToUpper(token.String)
+	color := strings.ToUpper(r.getWord())
 	if value, ok := COLORS[color]; ok {
 		return value
 	}
-	r.syntaxError(fmt.Sprintf("unrecognized color in line %d", token.Line))
+	r.syntaxError(fmt.Sprintf("unrecognized color in line %d", r.line()))
 	return Black // Dummy color
 }
 
@@ -230,88 +699,636 @@ func (r *Runtime) getParam(expected Token) ProgramStep {
 	return param
 }
 
-func (r *Runtime) push(val int) {
-	if r.SP == len(r.Stack) {
-		r.runtimeError(errors.New("stack overflow"))
+// line returns the source line of the last consumed step
+func (r *Runtime) line() uint32 {
+	switch {
+	case r.PC > 0:
+		return r.Program[r.PC-1].Line
+	case len(r.Program) > 0:
+		return r.Program[0].Line
 	}
-	r.Stack[r.SP] = val
-	r.SP += 1
+	return 0
 }
 
-func (r *Runtime) pop() int {
-	if r.SP == 0 {
-		r.runtimeError(errors.New("stack empty"))
+// file returns the loaded file of the last consumed step
+func (r *Runtime) file() string {
+	if r.PC > 0 && r.PC <= len(r.Program) {
+		return r.Program[r.PC-1].File
 	}
-	r.SP -= 1
-	return r.Stack[r.SP]
+	return ""
 }
 
-func NewRuntime() *Runtime {
-	return &Runtime{
-		Stub:    NewNullDraw(),
-		PC:      0,
-		SP:      0,
-		Head:    Position{X: 320, Y: 240},
-		Paper:   Black,
-		Ink:     White,
-		Program: []ProgramStep{},
-		Trace:   false,
+// evalParam reads a parameter of the expected type, the parameter is either
+// given directly or computed by an expression
+func (r *Runtime) evalParam(expected Token) Value {
+	if expected == TkIdent && r.isWord() {
+		return r.next().String
 	}
+
+	value := r.evaluate()
+	if expected == TkNumber {
+		return r.number(value)
+	}
+	return r.word(value)
+}
+
+// isWord checks whether the next step is a plain word, not the name of a
+// reporter or a procedure
+func (r *Runtime) isWord() bool {
+	if r.isEOP() || r.Program[r.PC].Token != TkIdent {
+		return false
+	}
+
+	name := strings.ToUpper(r.Program[r.PC].String)
+	_, reporter := r.Reporters[name]
+	_, procedure := r.Procedures[name]
+	return !reporter && !procedure
 }
 
-func (r *Runtime) DegToRad(deg int) float64 {
-	return float64(deg) * (math.Pi / 180)
+// report calls the reporter or the procedure named by the step, if there is
+// one
+func (r *Runtime) report(step ProgramStep) (Value, bool) {
+	name := strings.ToUpper(step.String)
+	if fn, ok := r.Reporters[name]; ok {
+		return fn(r), true
+	}
+
+	if proc, ok := r.Procedures[name]; ok {
+		value := r.call(proc)
+		if value == nil {
+			r.syntaxError(fmt.Sprintf("%s did not output in line %d", name, step.Line))
+		}
+		return value, true
+	}
+
+	return nil, false
 }
 
-func (r *Runtime) Run(program string) error {
-	l := NewLexer(program)
-	// l.Debug = true
-	r.Program = []ProgramStep{}
+// evaluate evaluates an expression with an optional comparison
+func (r *Runtime) evaluate() Value {
+	value := r.expression()
+	switch {
+	case r.isLiteral('<'):
+		r.next()
+		return boolWord(r.number(value) < r.number(r.expression()))
+	case r.isLiteral('>'):
+		r.next()
+		return boolWord(r.number(value) > r.number(r.expression()))
+	case r.isLiteral('='):
+		r.next()
+		return boolWord(equalValues(value, r.expression()))
+	}
+	return value
+}
 
-	// Parsing the source, building the program steps
+// expression evaluates sums and differences of terms
+func (r *Runtime) expression() Value {
+	value := r.term()
 	for {
-		token, err := l.NextToken()
-		if err != nil {
-			return err
+		switch {
+		case r.isLiteral('+'):
+			r.next()
+			value = r.number(value) + r.number(r.term())
+		case r.isLiteral('-'):
+			r.next()
+			value = r.number(value) - r.number(r.term())
+		default:
+			return value
 		}
+	}
+}
 
-		if token == TkEOF {
-			break
+// term evaluates products and quotients of factors
+func (r *Runtime) term() Value {
+	value := r.factor()
+	for {
+		switch {
+		case r.isLiteral('*'):
+			r.next()
+			value = r.number(value) * r.number(r.factor())
+		case r.isLiteral('/'):
+			r.next()
+			dividend := r.number(value)
+			divisor := r.number(r.factor())
+			if divisor == 0 {
+				r.syntaxError(fmt.Sprintf("division by zero in line %d", r.line()))
+			}
+			value = dividend / divisor
+		default:
+			return value
 		}
+	}
+}
 
-		switch token {
-		case TkIdent:
-			r.Program = append(r.Program, ProgramStep{Token: token, String: l.String, Line: l.Line})
+// factor evaluates a number, a variable, a reporter, a negated factor or an
+// expression in parentheses
+func (r *Runtime) factor() Value {
+	param := r.next()
+	switch {
+	case param.Token == TkNumber:
+		return param.Number
+	case param.Token == TkWord || param.Token == TkString:
+		return param.String
+	case param.Token == TkListOpen:
+		return r.listLiteral()
+	case param.Token == TkValue:
+		return param.Value
+	case param.Token == TkLiteral && param.Literal == '?':
+		return r.slot(0)
+	case param.Token == TkLiteral && param.Literal == '-':
+		return -r.number(r.factor())
+	case param.Token == TkLiteral && param.Literal == '(':
+		value := r.evaluate()
+		if closing := r.next(); closing.Token != TkLiteral || closing.Literal != ')' {
+			r.syntaxError(fmt.Sprintf("missing closing parenthesis in line %d", param.Line))
+		}
+		return value
+	case param.Token == TkLiteral && param.Literal == ':':
+		return r.variable(r.getParam(TkIdent))
+	case param.Token == TkIdent:
+		if index, ok := isSlot(param); ok {
+			return r.slot(index)
+		}
+		if value, ok := r.report(param); ok {
+			return value
+		}
+		r.syntaxError(fmt.Sprintf("unknown reporter %s in line %d", strings.ToUpper(param.String), param.Line))
+	}
+
+	r.syntaxError(fmt.Sprintf("unexpected token %d in line %d", param.Token, param.Line))
+	return nil // Dummy value
+}
+
+// variable looks up an input of the running procedure
+func (r *Runtime) variable(name ProgramStep) Value {
+	if len(r.Frames) != 0 {
+		if value, ok := r.Frames[len(r.Frames)-1].Locals[strings.ToUpper(name.String)]; ok {
+			return value
+		}
+	}
+
+	r.syntaxError(fmt.Sprintf("unknown variable %s in line %d", name.String, name.Line))
+	return nil // Dummy value
+}
+
+func (r *Runtime) number(value Value) float64 {
+	if number, ok := toNumber(value); ok {
+		return number
+	}
+
+	r.syntaxError(fmt.Sprintf("expected a number in line %d", r.line()))
+	return 0 // Dummy value
+}
+
+func (r *Runtime) word(value Value) string {
+	if word, ok := toWord(value); ok {
+		return word
+	}
+
+	r.syntaxError(fmt.Sprintf("expected a word in line %d", r.line()))
+	return "" // Dummy value
+}
+
+// listLiteral reads the items of a list up to the closing bracket, nothing
+// in a literal list is evaluated
+func (r *Runtime) listLiteral() []Value {
+	items := []Value{}
+	for {
+		item := r.next()
+		switch item.Token {
+		case TkListClose:
+			return items
+		case TkListOpen:
+			items = append(items, r.listLiteral())
 		case TkNumber:
-			r.Program = append(r.Program, ProgramStep{Token: token, Number: l.Number, Line: l.Line})
-		case TkLiteral: // skipped
-		case TkEOL: // skipped
-		case TkComment: // skipped
+			items = append(items, item.Number)
+		case TkLiteral:
+			items = append(items, string(item.Literal))
+		case TkWord:
+			items = append(items, "\""+item.String)
+		case TkValue:
+			items = append(items, item.Value)
 		default:
-			return fmt.Errorf("invalid token %d in line %d", token, l.Line)
+			items = append(items, item.String)
 		}
 	}
+}
+
+// truth converts the words TRUE and FALSE to a condition
+func (r *Runtime) truth(value Value) bool {
+	if word, ok := value.(string); ok {
+		switch strings.ToUpper(word) {
+		case "TRUE":
+			return true
+		case "FALSE":
+			return false
+		}
+	}
+
+	r.syntaxError(fmt.Sprintf("expected true or false in line %d", r.line()))
+	return false // Dummy value
+}
+
+// isLiteral checks the next step without consuming it
+func (r *Runtime) isLiteral(literal rune) bool {
+	return !r.isEOP() && r.Program[r.PC].Token == TkLiteral && r.Program[r.PC].Literal == literal
+}
+
+func (r *Runtime) getNumber() float64 {
+	return r.evalParam(TkNumber).(float64)
+}
+
+func (r *Runtime) getWord() string {
+	return r.evalParam(TkIdent).(string)
+}
+
+func (r *Runtime) getList() []Value {
+	value := r.evaluate()
+	if list, ok := value.([]Value); ok {
+		return list
+	}
+
+	r.syntaxError(fmt.Sprintf("expected a list in line %d", r.line()))
+	return nil // Dummy value
+}
+
+// statement runs a single command or procedure
+func (r *Runtime) statement() {
+	p := r.next()
+
+	if p.Token != TkIdent {
+		r.syntaxError(fmt.Sprintf("unexpected token %d in line %d", p.Token, p.Line))
+	}
+
+	cmd := strings.ToUpper(p.String)
+
+	if fn, ok := r.Keywords[cmd]; ok {
+		fn(r)
+	} else if proc, ok := r.Procedures[cmd]; ok {
+		locals := r.inputs(proc)
+		if r.isTail() {
+			panic(tailCall{procedure: proc, locals: locals})
+		}
+		if r.invoke(proc, locals) != nil {
+			r.syntaxError(fmt.Sprintf("you don't say what to do with the output of %s in line %d", cmd, p.Line))
+		}
+	} else {
+		r.syntaxError(fmt.Sprintf("unknown keyword in line %d", p.Line))
+	}
+}
+
+// block runs the statements of a bracketed instruction list
+func (r *Runtime) block() {
+	if open := r.next(); open.Token != TkListOpen {
+		r.syntaxError(fmt.Sprintf("expected instruction list in line %d", open.Line))
+	}
+
+	for r.isEOP() || r.Program[r.PC].Token != TkListClose {
+		r.statement()
+	}
+	r.next()
+}
+
+// skipBlock steps over a bracketed instruction list without running it
+func (r *Runtime) skipBlock() {
+	if open := r.next(); open.Token != TkListOpen {
+		r.syntaxError(fmt.Sprintf("expected instruction list in line %d", open.Line))
+	}
+
+	for depth := 1; depth > 0; {
+		switch r.next().Token {
+		case TkListOpen:
+			depth += 1
+		case TkListClose:
+			depth -= 1
+		}
+	}
+}
+
+// template turns a procedure name or a template list to program steps. A
+// procedure name gets the inputs right after it, a template list reads them
+// through its ? slots while it runs.
+func (r *Runtime) template(template Value, inputs []Value) []ProgramStep {
+	line := r.line()
+	list, ok := template.([]Value)
+	if !ok {
+		steps := []ProgramStep{{Token: TkIdent, String: r.word(template), Line: line}}
+		for _, input := range inputs {
+			steps = append(steps, ProgramStep{Token: TkValue, Value: input, Line: line})
+		}
+		return steps
+	}
+
+	steps, err := listSteps(list, line)
+	if err != nil {
+		r.runtimeError(err)
+	}
+	return steps
+}
+
+// apply evaluates the template with the inputs
+func (r *Runtime) apply(template Value, inputs ...Value) (value Value) {
+	r.runSteps(r.template(template, inputs), inputs, func() {
+		value = r.evaluate()
+		if !r.isEOP() {
+			r.syntaxError(fmt.Sprintf("too much inside the template in line %d", r.line()))
+		}
+	})
+	return
+}
+
+// instructions runs the steps as statements, the inputs fill the ? slots
+func (r *Runtime) instructions(steps []ProgramStep, inputs ...Value) {
+	r.runSteps(steps, inputs, func() {
+		for !r.isEOP() {
+			r.statement()
+		}
+	})
+}
+
+// runSteps makes the steps the running program for the time of fn
+func (r *Runtime) runSteps(steps []ProgramStep, inputs []Value, fn func()) {
+	program, pc, slots := r.Program, r.PC, r.slots
+	defer func() {
+		r.Program, r.PC, r.slots = program, pc, slots
+	}()
+
+	r.Program, r.PC = steps, 0
+	if inputs != nil {
+		r.slots = append(r.slots, inputs)
+	}
+	fn()
+}
+
+// slot outputs an input of the innermost running template
+func (r *Runtime) slot(index int) Value {
+	if len(r.slots) == 0 {
+		r.syntaxError(fmt.Sprintf("? outside of a template in line %d", r.line()))
+	}
+
+	inputs := r.slots[len(r.slots)-1]
+	if index >= len(inputs) {
+		r.syntaxError(fmt.Sprintf("template slot %d has no input in line %d", index+1, r.line()))
+	}
+	return inputs[index]
+}
+
+// catch runs fn, a THROW of the tag stops there. The tag ERROR stops the
+// runtime errors as well and keeps them for ERROR.
+func (r *Runtime) catch(tag string, fn func()) {
+	sp := r.SP
+	r.catches = append(r.catches, catch{tag: tag, frames: len(r.Frames)})
+	defer func() {
+		r.catches = r.catches[:len(r.catches)-1]
+		if e := recover(); e != nil {
+			switch e := e.(type) {
+			case thrown:
+				if e.tag == tag {
+					r.SP = sp
+					return
+				}
+			case error:
+				if tag == "ERROR" && !isGoRuntimeError(e) {
+					r.failure = []Value{errorMessage(e, r.failed), float64(r.failed)}
+					r.SP = sp
+					return
+				}
+			}
+			panic(e)
+		}
+	}()
+
+	fn()
+}
+
+// errorMessage strips the error down to the message ERROR outputs
+func errorMessage(err error, line uint32) string {
+	message := strings.TrimPrefix(err.Error(), "syntax error: ")
+	return strings.TrimSuffix(message, fmt.Sprintf(" in line %d", line))
+}
+
+// tailCall replaces the running procedure instead of nesting a new call
+type tailCall struct {
+	procedure *Procedure
+	locals    map[string]Value
+}
+
+// call runs a procedure and returns its output, nil if it has none
+func (r *Runtime) call(proc *Procedure) Value {
+	return r.invoke(proc, r.inputs(proc))
+}
+
+// inputs evaluates the inputs of a procedure call
+func (r *Runtime) inputs(proc *Procedure) map[string]Value {
+	locals := map[string]Value{}
+	for _, name := range proc.Params {
+		locals[name] = r.evaluate()
+	}
+	return locals
+}
+
+// isTail checks whether the call just read is the last thing the running
+// procedure does, that is only the ends of instruction lists follow and no
+// loop or CATCH of the procedure is running
+func (r *Runtime) isTail() bool {
+	if len(r.Frames) == 0 {
+		return false
+	}
+
+	if n := len(r.catches); n > 0 && r.catches[n-1].frames == len(r.Frames) {
+		return false
+	}
+
+	frame := r.Frames[len(r.Frames)-1]
+	if r.SP != frame.SP || !sameProgram(r.Program, frame.Procedure.Program) {
+		return false
+	}
+
+	for pc := r.PC; pc < frame.Procedure.End; pc++ {
+		if r.Program[pc].Token != TkListClose {
+			return false
+		}
+	}
+
+	return true
+}
+
+// invoke runs the body of the procedure, tail calls reuse the frame
+func (r *Runtime) invoke(proc *Procedure, locals map[string]Value) Value {
+	r.checkDepth()
+
+	program, pc, sp := r.Program, r.PC, r.SP
+	r.Frames = append(r.Frames, Frame{Procedure: proc, Locals: locals, SP: sp})
+	defer func() {
+		r.Frames = r.Frames[:len(r.Frames)-1]
+		r.Program, r.PC, r.SP = program, pc, sp
+	}()
 
-	// Running the program
-	r.PC = 0 // reset
 	for {
-		p := r.next()
+		frame := &r.Frames[len(r.Frames)-1]
+		r.trace(frame.Procedure.Name)
+		next, output := r.body(frame.Procedure)
+		if next == nil {
+			return output
+		}
 
-		if p.Token != TkIdent {
-			r.syntaxError(fmt.Sprintf("unexpected token %d in line %d", p.Token, p.Line))
+		frame.Procedure, frame.Locals = next.procedure, next.locals
+		r.SP = sp
+	}
+}
+
+// body runs the statements of a procedure until its END, OUTPUT, STOP or a
+// tail call
+func (r *Runtime) body(proc *Procedure) (next *tailCall, output Value) {
+	defer func() {
+		if exit := recover(); exit != nil {
+			switch exit := exit.(type) {
+			case procedureExit:
+				output = exit.value
+			case tailCall:
+				next = &exit
+			default:
+				panic(exit)
+			}
 		}
+	}()
+
+	r.Program, r.PC = proc.Program, proc.Start
+	for r.PC < proc.End {
+		r.statement()
+	}
 
-		cmd := strings.ToUpper(p.String)
+	return nil, nil
+}
 
-		if fn, ok := KEYWORDS[cmd]; ok {
-			fn(r)
-		} else {
-			r.syntaxError(fmt.Sprintf("unknown keyword in line %d", p.Line))
+// checkDepth stops runaway recursion before it exhausts the memory
+func (r *Runtime) checkDepth() {
+	if len(r.Frames)+r.SP/2 >= r.MaxDepth {
+		r.runtimeError(fmt.Errorf("stack overflow: more than %d nested loops and procedure calls in line %d", r.MaxDepth, r.line()))
+	}
+}
+
+func (r *Runtime) push(val int) {
+	r.Stack = append(r.Stack[:r.SP], val)
+	r.SP += 1
+}
+
+func (r *Runtime) pop() int {
+	if r.SP == 0 {
+		r.runtimeError(errors.New("stack empty"))
+	}
+	r.SP -= 1
+	return r.Stack[r.SP]
+}
+
+func NewRuntime() *Runtime {
+	keywords := make(map[string]Command, len(KEYWORDS))
+	for name, fn := range KEYWORDS {
+		keywords[name] = fn
+	}
+
+	reporters := make(map[string]Reporter, len(REPORTERS))
+	for name, fn := range REPORTERS {
+		reporters[name] = fn
+	}
+
+	return &Runtime{
+		Keywords:   keywords,
+		Reporters:  reporters,
+		Random:     NewMulberry32(time.Now().UnixNano()),
+		Stub:       NewNullDraw(),
+		Writer:     os.Stdout,
+		Path:       searchPath(),
+		Editor:     EditFile,
+		Procedures: map[string]*Procedure{},
+		PC:         0,
+		SP:         0,
+		MaxDepth:   10000,
+		Head:       Position{X: 320, Y: 240},
+		Paper:      Black,
+		Ink:        White,
+		Program:    []ProgramStep{},
+		Trace:      false,
+	}
+}
+
+// Register adds a primitive to this runtime only, replacing any keyword with
+// the same name.
+func (r *Runtime) Register(p Primitive) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+	if p.Run == nil {
+		return fmt.Errorf("primitive %s: missing runtime implementation", p.Name)
+	}
+
+	name := p.keyword()
+	r.Keywords[name] = func(r *Runtime) {
+		r.trace(name)
+		if err := p.Run(r, r.getArgs(p.Params)); err != nil {
+			r.runtimeError(err)
 		}
+	}
 
-		if r.isEOP() {
-			break
+	return nil
+}
+
+func (r *Runtime) getArgs(params []Token) []Value {
+	args := make([]Value, len(params))
+	for i, expected := range params {
+		args[i] = r.evalParam(expected)
+	}
+
+	return args
+}
+
+func isGoRuntimeError(err error) bool {
+	_, ok := err.(runtime.Error)
+	return ok
+}
+
+func (r *Runtime) DegToRad(deg float64) float64 {
+	return deg * (math.Pi / 180)
+}
+
+func (r *Runtime) RadToDeg(rad float64) float64 {
+	return rad * (180 / math.Pi)
+}
+
+func (r *Runtime) Run(program string) (err error) {
+	// Parsing the source and the loaded files, building the program steps
+	r.Program, err = parse(program, r.Path)
+	if err != nil {
+		return err
+	}
+
+	procedures, err := scanProcedures(r.Program)
+	if err != nil {
+		return err
+	}
+	r.defined = procedures
+	if err := r.define(procedures); err != nil {
+		return err
+	}
+
+	// Running the program, runtime errors unwind the Go stack up to here
+	defer func() {
+		if e := recover(); e != nil {
+			if failure, ok := e.(error); ok && !isGoRuntimeError(failure) {
+				err = fileError(r.failedFile, failure)
+				return
+			}
+			panic(e)
 		}
+	}()
+
+	r.PC = 0 // reset
+	r.SP = 0
+	r.Frames = []Frame{}
+	r.catches = nil
+	r.failure = nil
+	for !r.isEOP() {
+		r.statement()
 	}
 
 	return nil
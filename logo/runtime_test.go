@@ -0,0 +1,140 @@
+package logo
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// A program, what it prints and the error it stops with, if any
+type runTest struct {
+	name    string
+	program string
+	output  string
+	err     string
+}
+
+func runTests(t *testing.T, tests []runTest) {
+	t.Helper()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := run(t, NewRuntime(), test.program)
+			if output != test.output {
+				t.Errorf("printed %q, want %q", output, test.output)
+			}
+
+			switch {
+			case test.err == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case test.err != "" && err == nil:
+				t.Errorf("no error, want %q", test.err)
+			case test.err != "" && !strings.Contains(err.Error(), test.err):
+				t.Errorf("error %q, want %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestExpressions(t *testing.T) {
+	runTests(t, []runTest{
+		{"precedence", "print 1 + 2 * 3", "7\n", ""},
+		{"parentheses", "print (1 + 2) * 3", "9\n", ""},
+		{"fraction", "print 7 / 2", "3.5\n", ""},
+		{"left to right", "print 10 - 4 - 3", "3\n", ""},
+		{"negation", "print -3 + 1", "-2\n", ""},
+		{"mixed", "print 2 * (3 + 4) / 7", "2\n", ""},
+		{"reporter takes the expression", "print sqrt 9 + 7", "4\n", ""},
+		{"power", "print power 2 10", "1024\n", ""},
+		{"abs", "print abs -4", "4\n", ""},
+		{"int", "print int 3.7 print int -3.7", "3\n-3\n", ""},
+		{"round half away from zero", "print round 2.5 print round -2.5", "3\n-3\n", ""},
+		{"modulo has the sign of the divisor", "print modulo -7 3 print modulo 7 (-3)", "2\n-2\n", ""},
+		{"degrees", "print sin 30 print arctan 1", "0.49999999999999994\n45\n", ""},
+		{"comparison", "print 2 < 3 print 3 = 3.0", "true\ntrue\n", ""},
+		{"division by zero", "print 1 / 0", "", "division by zero in line 1"},
+		{"negative square root", "print sqrt -1", "", "square root of a negative number in line 1"},
+		{"not a number", "print 1 < \"a", "", "expected a number in line 1"},
+		{"expression as a count", "repeat 1 + 2 type \"x loop", "xxx", ""},
+		{"fraction of a count dropped", "repeat 2.5 type \"x loop", "xx", ""},
+		{"count below one", "repeat 0.5 type \"x loop", "", "the count is too small or too large number in line 1"},
+	})
+}
+
+func TestProcedures(t *testing.T) {
+	runTests(t, []runTest{
+		{"output", "to double :n output :n * 2 end print double 21", "42\n", ""},
+		{"inputs in order", "to minus :x :y output :x - :y end print minus 10 3", "7\n", ""},
+		{"called before defined", "print later 3 to later :n output :n + 1 end", "4\n", ""},
+		{"stop", "to hello print \"hi stop print \"no end hello", "hi\n", ""},
+		{"stop without output", "to f :x if :x > 3 [output \"big] stop end print f 1", "", "F did not output in line 1"},
+		{"end without output", "to f :x if :x > 3 [output \"big] end print f 1", "", "F did not output in line 1"},
+		{"no output used", "to g print 1 end print g", "1\n", "G did not output in line 1"},
+		{"output ignored", "to f output 1 end f", "", "you don't say what to do with the output of F in line 1"},
+		{"output outside", "output 1", "", "OUTPUT outside of a procedure in line 1"},
+		{"stop outside", "stop", "", "STOP outside of a procedure in line 1"},
+		{"if and ifelse", "ifelse 1 < 2 [print \"yes] [print \"no] if 1 > 2 [print \"never]", "yes\n", ""},
+	})
+}
+
+func TestTailCalls(t *testing.T) {
+	// far deeper than MaxDepth, the tail calls reuse the frame
+	runTests(t, []runTest{
+		{"command", "to down :n if :n = 0 [stop] down :n - 1 end down 100000 print \"done", "done\n", ""},
+		{"if at the end", "to spiral :n forward 1 if :n < 100000 [spiral :n + 1] end spiral 0 print \"done", "done\n", ""},
+		{"reporters nest", "to sum :n if :n = 0 [output 0] output :n + sum :n - 1 end print sum 1000", "500500\n", ""},
+		{"not a tail call", "to deep :n if :n > 0 [deep :n - 1 print :n] end deep 100000", "", "stack overflow: more than 10000 nested loops and procedure calls"},
+	})
+}
+
+func TestMaxDepth(t *testing.T) {
+	program := "to deep :n if :n > 0 [deep :n - 1] forward 1 end deep %d"
+
+	r := NewRuntime()
+	r.MaxDepth = 50
+	if _, err := run(t, r, fmt.Sprintf(program, 40)); err != nil {
+		t.Fatalf("40 calls: %v", err)
+	}
+
+	_, err := run(t, r, fmt.Sprintf(program, 60))
+	if err == nil || !strings.Contains(err.Error(), "stack overflow: more than 50 nested loops and procedure calls") {
+		t.Fatalf("60 calls: error %v", err)
+	}
+
+	// the loops count as well
+	_, err = run(t, r, "to nest :n if :n > 0 [repeat 1 nest :n - 1 loop] forward 1 end nest 40")
+	if err == nil || !strings.Contains(err.Error(), "stack overflow") {
+		t.Fatalf("40 calls in loops: error %v", err)
+	}
+}
+
+func TestLists(t *testing.T) {
+	runTests(t, []runTest{
+		{"nested literal", "show [10 20 [30 40]]", "[10 20 [30 40]]\n", ""},
+		{"numbers are words", "print first 123 print last \"hello", "1\no\n", ""},
+		{"butfirst and butlast", "show bf [1 2 3] show bl \"hello", "[2 3]\nhell\n", ""},
+		{"item", "print item 2 [a b c]", "b\n", ""},
+		{"item out of range", "print item 4 [a b c]", "", "ITEM 4 is out of range in line 1"},
+		{"count", "print count [1 [2 3] 4] print count \"abcd", "3\n4\n", ""},
+		{"fput and lput", "show fput 1 [2 3] show lput 4 [2 3]", "[1 2 3]\n[2 3 4]\n", ""},
+		{"sentence", "show se [a b] \"c show se \"a \"b", "[a b c]\n[a b]\n", ""},
+		{"word", "print word \"ab 12", "ab12\n", ""},
+		{"word is a number", "print (word 1 2) + 1", "13\n", ""},
+		{"emptyp", "print emptyp [] print emptyp \"x", "true\nfalse\n", ""},
+		{"equal", "print [1 2] = [1 2] print \"Abc = \"aBC print [1 [2]] = [1 [3]]", "true\ntrue\nfalse\n", ""},
+		{"first of empty", "print first []", "", "FIRST of an empty word or list in line 1"},
+	})
+}
+
+func TestCatch(t *testing.T) {
+	runTests(t, []runTest{
+		{"throw", "catch \"done [print 1 throw \"done print 2] print 3", "1\n3\n", ""},
+		{"outer tag", "catch \"a [catch \"b [throw \"a] print \"inner] print \"outer", "outer\n", ""},
+		{"tags ignore the case", "catch \"Done [throw \"dONE] print \"ok", "ok\n", ""},
+		{"no catch", "throw \"nowhere", "", "no CATCH for NOWHERE in line 1"},
+		{"error", "catch \"error [print 1 / 0] show error", "[division by zero 1]\n", ""},
+		{"error once", "catch \"error [print 1 / 0] print first error show error", "division by zero\n[]\n", ""},
+		{"no error", "show error", "[]\n", ""},
+		{"other tags let errors through", "catch \"x [print 1 / 0]", "", "division by zero in line 1"},
+		{"output from catch", "to ratio :a :b catch \"error [output :a / :b] output 0 end print ratio 6 3 print ratio 1 0", "2\n0\n", ""},
+	})
+}
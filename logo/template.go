@@ -0,0 +1,58 @@
+package logo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// isSlot checks for a template slot, ? or ?<n>, and returns its index
+func isSlot(step ProgramStep) (int, bool) {
+	if step.Token == TkLiteral && step.Literal == '?' {
+		return 0, true
+	}
+
+	var index int
+	if step.Token == TkIdent && strings.HasPrefix(step.String, "?") {
+		if _, err := fmt.Sscanf(step.String, "?%d", &index); err == nil && index > 0 {
+			return index - 1, true
+		}
+	}
+	return 0, false
+}
+
+// listSteps turns a list back to program steps, so it can be run as
+// instructions. The words of the list are lexed again.
+func listSteps(list []Value, line uint32) ([]ProgramStep, error) {
+	steps := []ProgramStep{}
+	for _, item := range list {
+		switch v := item.(type) {
+		case []Value:
+			nested, err := listSteps(v, line)
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, ProgramStep{Token: TkListOpen, Literal: '[', Line: line})
+			steps = append(steps, nested...)
+			steps = append(steps, ProgramStep{Token: TkListClose, Literal: ']', Line: line})
+		case float64:
+			steps = append(steps, ProgramStep{Token: TkNumber, Number: v, Line: line})
+		case string:
+			l := NewLexer(v)
+			for {
+				token, err := l.NextToken()
+				if err != nil {
+					return nil, fmt.Errorf("%s in a list run as instructions in line %d", err, line)
+				}
+				if token == TkEOF {
+					break
+				}
+				steps = append(steps, ProgramStep{Token: token, String: l.String, Number: l.Number, Literal: l.Literal, Line: line})
+			}
+		}
+	}
+	return steps, nil
+}
+
+func sameProgram(a, b []ProgramStep) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
@@ -0,0 +1,21 @@
+package logo
+
+import "testing"
+
+func TestTemplates(t *testing.T) {
+	runTests(t, []runTest{
+		{"run", "run [print \"hello print 1 + 2]", "hello\n3\n", ""},
+		{"map", "print map [? * 2] [1 2 3]", "2 4 6\n", ""},
+		{"map a procedure", "to double :x output :x * 2 end print map \"double [1 2 3]", "2 4 6\n", ""},
+		{"nested templates", "print map [map [? + 1] ?] [[1 2] [3]]", "[2 3] [4]\n", ""},
+		{"filter", "print filter [? > 1] [1 2 3]", "2 3\n", ""},
+		{"reduce from the right", "print reduce [?1 - ?2] [10 3 2]", "9\n", ""},
+		{"reduce a procedure", "print reduce \"word [a b c]", "abc\n", ""},
+		{"reduce empty", "print reduce [?1 + ?2] []", "", "REDUCE of an empty word or list in line 1"},
+		{"foreach", "foreach [10 20] [print ?]", "10\n20\n", ""},
+		{"foreach a primitive", "foreach [1 2] \"print", "1\n2\n", ""},
+		{"apply as a reporter", "print apply [?1 + ?2] [3 4]", "7\n", ""},
+		{"apply as a command", "to shout :n print :n end apply \"shout [5]", "5\n", ""},
+		{"slot outside", "print ?", "", "? outside of a template in line 1"},
+	})
+}
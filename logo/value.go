@@ -0,0 +1,93 @@
+package logo
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Value is a Logo datum: a number (float64), a word (string) or a list
+// ([]Value). Numbers are words as well, so they can be taken apart.
+type Value any
+
+func formatNumber(number float64) string {
+	return strconv.FormatFloat(number, 'f', -1, 64)
+}
+
+// toNumber converts numbers and words that look like numbers
+func toNumber(value Value) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		if v == "" || strings.ContainsAny(v, "iInNxXpP_") {
+			return 0, false // infinity, NaN, hexadecimal and underscores are words
+		}
+		number, err := strconv.ParseFloat(v, 64)
+		return number, err == nil
+	}
+	return 0, false
+}
+
+// toWord converts words and numbers, lists are not words
+func toWord(value Value) (string, bool) {
+	switch v := value.(type) {
+	case float64:
+		return formatNumber(v), true
+	case string:
+		return v, true
+	}
+	return "", false
+}
+
+// equalValues compares numerically when both values are numbers, words are
+// compared ignoring case and lists item by item
+func equalValues(a, b Value) bool {
+	if x, ok := toNumber(a); ok {
+		if y, ok := toNumber(b); ok {
+			return x == y
+		}
+	}
+
+	if x, ok := toWord(a); ok {
+		y, ok := toWord(b)
+		return ok && strings.EqualFold(x, y)
+	}
+
+	x, _ := a.([]Value)
+	y, ok := b.([]Value)
+	if !ok || len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if !equalValues(x[i], y[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func boolWord(value bool) Value {
+	if value {
+		return "true"
+	}
+	return "false"
+}
+
+// FormatValue prints a value the way PRINT does, lists without the outer
+// brackets
+func FormatValue(value Value) string {
+	if list, ok := value.([]Value); ok {
+		items := make([]string, len(list))
+		for i, item := range list {
+			if _, nested := item.([]Value); nested {
+				items[i] = "[" + FormatValue(item) + "]"
+			} else {
+				items[i] = FormatValue(item)
+			}
+		}
+		return strings.Join(items, " ")
+	}
+
+	word, _ := toWord(value)
+	return word
+}
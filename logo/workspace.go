@@ -0,0 +1,293 @@
+package logo
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// The procedures are the workspace of a Runtime, they stay defined from one
+// Run to the next. PO, POTS, SAVE, EDIT and ERASE look at and change it. The
+// language has no global variables, so the procedures are all there is.
+
+// FormatProcedure turns a procedure back to source text. The text is in the
+// canonical form: the title line, the body with one line for every source
+// line, indented by the nesting of the lists and loops, and END. Comments
+// are not kept.
+func FormatProcedure(proc *Procedure) string {
+	var sb strings.Builder
+	sb.WriteString(title(proc))
+	sb.WriteString("\n")
+
+	depth, line := 1, uint32(0)
+	for pc := proc.Start; pc < proc.End; pc++ {
+		step := proc.Program[pc]
+		closing := step.Token == TkListClose || isKeyword(step, "LOOP")
+		if pc == proc.Start || step.Line != line {
+			if pc != proc.Start {
+				sb.WriteString("\n")
+			}
+
+			indent := depth
+			if closing {
+				indent -= 1
+			}
+			sb.WriteString(strings.Repeat("\t", max(indent, 1)))
+		} else if !glued(proc.Program[pc-1], step) {
+			sb.WriteString(" ")
+		}
+
+		sb.WriteString(formatStep(step))
+		line = step.Line
+		switch {
+		case step.Token == TkListOpen || isKeyword(step, "REPEAT"):
+			depth += 1
+		case closing:
+			depth -= 1
+		}
+	}
+
+	if proc.End > proc.Start {
+		sb.WriteString("\n")
+	}
+	sb.WriteString("end\n")
+	return sb.String()
+}
+
+// title returns the TO line of the procedure, as it was written
+func title(proc *Procedure) string {
+	to := proc.Start - 2*len(proc.Params) - 2
+	words := []string{"to", proc.Program[to+1].String}
+	for pc := to + 2; pc < proc.Start; pc += 2 {
+		words = append(words, ":"+proc.Program[pc+1].String)
+	}
+	return strings.Join(words, " ")
+}
+
+// glued checks whether the step follows the previous one without a space
+func glued(previous, step ProgramStep) bool {
+	switch {
+	case previous.Token == TkListOpen || step.Token == TkListClose:
+		return true
+	case previous.Token == TkLiteral && (previous.Literal == ':' || previous.Literal == '('):
+		return true
+	case step.Token == TkLiteral && step.Literal == ')':
+		return true
+	}
+	return false
+}
+
+func formatStep(step ProgramStep) string {
+	switch step.Token {
+	case TkNumber:
+		return formatNumber(step.Number)
+	case TkWord:
+		return "\"" + step.String
+	case TkString:
+		return "'" + step.String + "'"
+	case TkLiteral, TkListOpen, TkListClose:
+		return string(step.Literal)
+	case TkValue:
+		return FormatValue(step.Value)
+	}
+	return step.String
+}
+
+// Save writes all procedures of the workspace as source text, sorted by name
+func (r *Runtime) Save(w io.Writer) error {
+	for i, name := range r.procedureNames() {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := io.WriteString(w, FormatProcedure(r.Procedures[name])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Define adds the procedures of the source to the workspace, replacing the
+// ones with the same name. The source must have nothing but definitions.
+func (r *Runtime) Define(source string) error {
+	program, err := parse(source, r.Path)
+	if err != nil {
+		return err
+	}
+
+	procedures, err := scanProcedures(program)
+	if err != nil {
+		return err
+	}
+
+	for pc := 0; pc < len(program); pc++ {
+		proc, ok := definedAt(procedures, program, pc)
+		if !ok {
+			return fileError(program[pc].File, fmt.Errorf("syntax error: only procedure definitions are allowed in line %d", program[pc].Line))
+		}
+		pc = proc.End
+	}
+
+	return r.define(procedures)
+}
+
+// definedAt finds the procedure whose TO is the step
+func definedAt(procedures map[string]*Procedure, program []ProgramStep, pc int) (*Procedure, bool) {
+	if !isKeyword(program[pc], "TO") || pc+1 == len(program) {
+		return nil, false
+	}
+	proc, ok := procedures[strings.ToUpper(program[pc+1].String)]
+	return proc, ok
+}
+
+// define puts the procedures to the workspace
+func (r *Runtime) define(procedures map[string]*Procedure) error {
+	for name, proc := range procedures {
+		_, keyword := r.Keywords[name]
+		_, reporter := r.Reporters[name]
+		if keyword || reporter {
+			return fileError(proc.Program[proc.Start-1].File, fmt.Errorf("syntax error: %s is a primitive and cannot be redefined in line %d", name, proc.Line))
+		}
+	}
+
+	if r.Procedures == nil {
+		r.Procedures = map[string]*Procedure{}
+	}
+	for name, proc := range procedures {
+		r.Procedures[name] = proc
+	}
+	return nil
+}
+
+// procedureNames returns the names of the procedures in the workspace, sorted
+func (r *Runtime) procedureNames() []string {
+	names := make([]string, 0, len(r.Procedures))
+	for name := range r.Procedures {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// getProcedures reads a procedure name or a list of them
+func (r *Runtime) getProcedures() []*Procedure {
+	value := r.evaluate()
+	names, ok := value.([]Value)
+	if !ok {
+		names = []Value{value}
+	}
+
+	procedures := make([]*Procedure, len(names))
+	for i, name := range names {
+		proc, ok := r.Procedures[strings.ToUpper(r.word(name))]
+		if !ok {
+			r.syntaxError(fmt.Sprintf("%s is not defined in line %d", r.word(name), r.line()))
+		}
+		procedures[i] = proc
+	}
+	return procedures
+}
+
+// poCmd prints out the definitions of the procedures
+func poCmd(r *Runtime) {
+	r.trace("PO")
+	for _, proc := range r.getProcedures() {
+		r.write(FormatProcedure(proc))
+	}
+}
+
+// potsCmd prints the titles of all procedures
+func potsCmd(r *Runtime) {
+	r.trace("POTS")
+	for _, name := range r.procedureNames() {
+		r.write(title(r.Procedures[name]) + "\n")
+	}
+}
+
+func eraseCmd(r *Runtime) {
+	r.trace("ERASE")
+	for _, proc := range r.getProcedures() {
+		delete(r.Procedures, proc.Name)
+	}
+}
+
+func saveCmd(r *Runtime) {
+	r.trace("SAVE")
+	name := r.getWord()
+	file, err := os.Create(name)
+	if err != nil {
+		r.runtimeError(fmt.Errorf("cannot save %s in line %d: %w", name, r.line(), err))
+	}
+	defer file.Close()
+
+	if err := r.Save(file); err != nil {
+		r.runtimeError(fmt.Errorf("cannot save %s in line %d: %w", name, r.line(), err))
+	}
+}
+
+// editCmd hands the definitions to the editor and defines what comes back,
+// a name that is not defined yet starts an empty procedure
+func editCmd(r *Runtime) {
+	r.trace("EDIT")
+	value := r.evaluate()
+	names, ok := value.([]Value)
+	if !ok {
+		names = []Value{value}
+	}
+
+	var sb strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		if proc, ok := r.Procedures[strings.ToUpper(r.word(name))]; ok {
+			sb.WriteString(FormatProcedure(proc))
+		} else {
+			sb.WriteString("to " + r.word(name) + "\nend\n")
+		}
+	}
+
+	line := r.line()
+	text, err := r.Editor(sb.String())
+	if err != nil {
+		r.runtimeError(fmt.Errorf("cannot edit in line %d: %w", line, err))
+	}
+	if err := r.Define(text); err != nil {
+		r.runtimeError(fmt.Errorf("edited procedures in line %d: %w", line, err))
+	}
+}
+
+// EditFile is the default editor of EDIT, it opens the text in $EDITOR
+func EditFile(text string) (string, error) {
+	file, err := os.CreateTemp("", "logo-*.logo")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.WriteString(text); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+
+	cmd := exec.Command(editor, file.Name())
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	edited, err := os.ReadFile(file.Name())
+	return string(edited), err
+}
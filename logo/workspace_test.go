@@ -0,0 +1,101 @@
+package logo
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The workspace in the canonical form of SAVE
+const workspace = `to flower :n :size
+	repeat :n
+		petal :size
+		right 360 / :n
+	loop
+end
+
+to petal :size
+	repeat 2
+		arc 60 :size
+		right 120
+	loop
+	if :size > 10 [petal :size / 2]
+end
+
+to ratio :a :b
+	output (:a + 1) / :b
+end
+`
+
+// save returns the workspace of the runtime as SAVE writes it
+func save(t *testing.T, r *Runtime) string {
+	t.Helper()
+	var sb strings.Builder
+	if err := r.Save(&sb); err != nil {
+		t.Fatal(err)
+	}
+	return sb.String()
+}
+
+func TestSaveRoundTrip(t *testing.T) {
+	r := NewRuntime()
+	if err := r.Define(workspace); err != nil {
+		t.Fatal(err)
+	}
+	saved := save(t, r)
+	if saved != workspace {
+		t.Fatalf("saved\n%s\nwant\n%s", saved, workspace)
+	}
+
+	// what is saved defines the same procedures
+	again := NewRuntime()
+	if err := again.Define(saved); err != nil {
+		t.Fatal(err)
+	}
+	if s := save(t, again); s != saved {
+		t.Fatalf("saved again\n%s\nthe first time\n%s", s, saved)
+	}
+	output, err := run(t, again, "print ratio 5 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != "3\n" {
+		t.Fatalf("printed %q", output)
+	}
+}
+
+func TestFormatProcedureIsCanonical(t *testing.T) {
+	r := NewRuntime()
+	err := r.Define("to   square :n\n# a comment\nrepeat 4 [ forward :n right 90 ]\nend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "to square :n\n\trepeat 4 [forward :n right 90]\nend\n"
+	if text := FormatProcedure(r.Procedures["SQUARE"]); text != want {
+		t.Fatalf("formatted %q, want %q", text, want)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "saved.logo")
+
+	r := NewRuntime()
+	if _, err := run(t, r, workspace+"save \""+file); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewRuntime()
+	if _, err := run(t, loaded, "load \""+file); err != nil {
+		t.Fatal(err)
+	}
+	if saved := save(t, loaded); saved != workspace {
+		t.Fatalf("loaded\n%s\nwant\n%s", saved, workspace)
+	}
+}
+
+func TestDefineOnlyDefinitions(t *testing.T) {
+	err := NewRuntime().Define("to f end\nprint 1")
+	if err == nil || !strings.Contains(err.Error(), "only procedure definitions are allowed in line 2") {
+		t.Fatalf("error %v", err)
+	}
+}
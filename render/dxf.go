@@ -0,0 +1,141 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"rs.lab/go-logo/logo"
+)
+
+// ACI are the AutoCAD color numbers of the inks
+var ACI = map[logo.Color]int{
+	logo.Black:   7, // black or white, against the background
+	logo.White:   255,
+	logo.Red:     1,
+	logo.Green:   3,
+	logo.Blue:    5,
+	logo.Yellow:  2,
+	logo.Gray:    8,
+	logo.Magenta: 6,
+}
+
+// DXF is a drawing stub that writes the strokes as an R12 ASCII DXF file for
+// CAD tools. Every ink has its layer, a single stroke is a LINE and joined
+// strokes make a POLYLINE; R12 has no LWPOLYLINE, it came with R14. All
+// pages are drawn over each other.
+type DXF struct {
+	Recorder
+	Scale float64 // drawing units for a unit of the canvas
+	FlipY bool    // the Y axis of the drawing grows upward
+}
+
+func NewDXF() *DXF {
+	return &DXF{Scale: 0.25, FlipY: true}
+}
+
+func (d *DXF) WriteTo(w io.Writer) (int64, error) {
+	polylines := Polylines(d.Strokes())
+
+	inks := []logo.Color{}
+	for _, polyline := range polylines {
+		if !slices.Contains(inks, polyline.Ink) {
+			inks = append(inks, polyline.Ink)
+		}
+	}
+	slices.Sort(inks)
+
+	var buffer bytes.Buffer
+	group := func(code int, value any) {
+		fmt.Fprintf(&buffer, "%3d\n%v\n", code, value)
+	}
+
+	group(0, "SECTION")
+	group(2, "HEADER")
+	group(9, "$ACADVER")
+	group(1, "AC1009")
+	group(0, "ENDSEC")
+
+	group(0, "SECTION")
+	group(2, "TABLES")
+	group(0, "TABLE")
+	group(2, "LTYPE")
+	group(70, 1)
+	group(0, "LTYPE")
+	group(2, "CONTINUOUS")
+	group(70, 0)
+	group(3, "Solid line")
+	group(72, 65)
+	group(73, 0)
+	group(40, "0.0")
+	group(0, "ENDTAB")
+	group(0, "TABLE")
+	group(2, "LAYER")
+	group(70, len(inks))
+	for _, ink := range inks {
+		group(0, "LAYER")
+		group(2, layer(ink))
+		group(70, 0)
+		group(62, ACI[ink])
+		group(6, "CONTINUOUS")
+	}
+	group(0, "ENDTAB")
+	group(0, "ENDSEC")
+
+	group(0, "SECTION")
+	group(2, "ENTITIES")
+	for _, polyline := range polylines {
+		name := layer(polyline.Ink)
+		if len(polyline.Points) == 2 {
+			group(0, "LINE")
+			group(8, name)
+			d.point(group, 10, polyline.Points[0])
+			d.point(group, 11, polyline.Points[1])
+			continue
+		}
+
+		points := polyline.Points
+		flags := 0
+		if polyline.Closed() {
+			points, flags = points[:len(points)-1], 1
+		}
+
+		group(0, "POLYLINE")
+		group(8, name)
+		group(66, 1)
+		group(10, 0) // the position of a POLYLINE itself is always zero
+		group(20, 0)
+		group(30, 0)
+		group(70, flags)
+		for _, point := range points {
+			group(0, "VERTEX")
+			group(8, name)
+			d.point(group, 10, point)
+		}
+		group(0, "SEQEND")
+		group(8, name)
+	}
+	group(0, "ENDSEC")
+	group(0, "EOF")
+
+	return buffer.WriteTo(w)
+}
+
+// point writes the coordinates with the group codes starting at code
+func (d *DXF) point(group func(int, any), code int, point Point) {
+	x, y := float64(point.X), float64(point.Y)
+	if d.FlipY {
+		y = Height - y
+	}
+
+	group(code, decimal(x*d.Scale))
+	group(code+10, decimal(y*d.Scale))
+	group(code+20, 0)
+}
+
+// layer names the layer of the ink
+func layer(ink logo.Color) string {
+	return strings.ToUpper(colorName(ink))
+}
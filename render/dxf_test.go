@@ -0,0 +1,103 @@
+package render
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// dxfGroup is a group code and its value
+type dxfGroup struct {
+	code  int
+	value string
+}
+
+// dxfGroups reads the pairs of lines of a DXF file
+func dxfGroups(t *testing.T, document string) []dxfGroup {
+	t.Helper()
+	lines := strings.Split(strings.TrimSuffix(document, "\n"), "\n")
+	if len(lines)%2 != 0 {
+		t.Fatalf("%d lines, a group is a code and a value", len(lines))
+	}
+
+	groups := make([]dxfGroup, 0, len(lines)/2)
+	for i := 0; i < len(lines); i += 2 {
+		code, err := strconv.Atoi(strings.TrimSpace(lines[i]))
+		if err != nil || len(lines[i]) != 3 {
+			t.Fatalf("line %d: %q is not a group code", i+1, lines[i])
+		}
+		groups = append(groups, dxfGroup{code, lines[i+1]})
+	}
+	return groups
+}
+
+// dxfEntities describes the entities, a line for each with its layer, the
+// flags of a POLYLINE and the points of its vertices
+func dxfEntities(groups []dxfGroup) []string {
+	entities := []string{}
+	start := 0
+	for i, group := range groups {
+		if group.code == 2 && group.value == "ENTITIES" {
+			start = i + 1
+		}
+	}
+
+	for i := start; i < len(groups); i++ {
+		group := groups[i]
+		switch {
+		case group.code == 0 && (group.value == "LINE" || group.value == "POLYLINE"):
+			entities = append(entities, group.value+" "+groups[i+1].value)
+		case group.code == 0 && group.value == "ENDSEC":
+			return entities
+		case group.code == 70:
+			entities[len(entities)-1] += " flags " + group.value
+		case group.code == 10 && groups[i-1].code != 66: // not the position of the POLYLINE
+			fallthrough
+		case group.code == 11:
+			entities[len(entities)-1] += " " + group.value + "," + groups[i+1].value
+		}
+	}
+	return entities
+}
+
+func TestDXF(t *testing.T) {
+	dxf := NewDXF()
+	runOn(t, dxf, pages+"right 90 forward 20 right 90 forward 20 right 90 forward 20")
+	groups := dxfGroups(t, write(t, dxf))
+
+	if groups[len(groups)-1] != (dxfGroup{0, "EOF"}) {
+		t.Errorf("the file ends with %v", groups[len(groups)-1])
+	}
+	sections := []string{}
+	for i, group := range groups {
+		if group.code == 0 && group.value == "SECTION" {
+			sections = append(sections, groups[i+1].value)
+		}
+	}
+	if strings.Join(sections, " ") != "HEADER TABLES ENTITIES" {
+		t.Errorf("the sections are %v", sections)
+	}
+
+	// A layer for every ink, in the order of the colors, with its color number
+	layers := []string{}
+	for i, group := range groups {
+		if group.code == 0 && group.value == "LAYER" {
+			layers = append(layers, groups[i+1].value+" "+groups[i+3].value)
+		}
+	}
+	if got := strings.Join(layers, ", "); got != "RED 1, GREEN 3, BLUE 5" {
+		t.Errorf("the layers are %s", got)
+	}
+
+	// A single stroke is a LINE, joined strokes a POLYLINE; the pages are
+	// drawn over each other and the closed square has the flag 1 without
+	// the last point
+	want := []string{
+		"POLYLINE RED flags 0 80,60 82.5,60 82.5,62.5",
+		"LINE BLUE 82.5,65 82.5,67.5",
+		"POLYLINE GREEN flags 1 80,60 80,65 75,65 75,60",
+	}
+	if got := dxfEntities(groups); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("the entities are\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,85 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"rs.lab/go-logo/logo"
+)
+
+// GCode is a drawing stub that writes the strokes as G-code for pen plotters
+// and laser engravers. The pen moves up between strokes that do not join,
+// the machine pauses (M0) for the paper to be changed between pages.
+type GCode struct {
+	Recorder
+	Scale   float64 // millimetres for a unit of the canvas
+	Feed    float64 // the speed of the drawing moves, in mm/min
+	PenUp   string  // lifts the pen or turns the laser off, like M5 or G0 Z5
+	PenDown string  // lowers the pen or turns the laser on, like M3 or G1 Z0
+	FlipY   bool    // the Y axis of the machine grows upward
+}
+
+func NewGCode() *GCode {
+	return &GCode{Scale: 0.25, Feed: 1500, PenUp: "M5", PenDown: "M3", FlipY: true}
+}
+
+func (g *GCode) WriteTo(w io.Writer) (int64, error) {
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "; %s x %s mm\n", decimal(Width*g.Scale), decimal(Height*g.Scale))
+	buffer.WriteString("G21 ; millimetres\n")
+	buffer.WriteString("G90 ; absolute positions\n")
+	fmt.Fprintf(&buffer, "%s\n", g.PenUp)
+
+	down := false
+	x, y := int32(0), int32(0)
+	for i, page := range g.Pages {
+		if i > 0 {
+			if down {
+				fmt.Fprintf(&buffer, "%s\n", g.PenUp)
+				down = false
+			}
+			buffer.WriteString("M0 ; next page\n")
+		}
+
+		ink := logo.Color(-1)
+		for _, stroke := range page.Strokes {
+			if stroke.Ink != ink {
+				fmt.Fprintf(&buffer, "; ink %s\n", colorName(stroke.Ink))
+				ink = stroke.Ink
+			}
+
+			if !down || stroke.X1 != x || stroke.Y1 != y {
+				if down {
+					fmt.Fprintf(&buffer, "%s\n", g.PenUp)
+				}
+				fmt.Fprintf(&buffer, "G0 X%s Y%s\n", g.x(stroke.X1), g.y(stroke.Y1))
+				fmt.Fprintf(&buffer, "%s\n", g.PenDown)
+				fmt.Fprintf(&buffer, "G1 X%s Y%s F%s\n", g.x(stroke.X2), g.y(stroke.Y2), decimal(g.Feed))
+				down = true
+			} else {
+				fmt.Fprintf(&buffer, "G1 X%s Y%s\n", g.x(stroke.X2), g.y(stroke.Y2))
+			}
+			x, y = stroke.X2, stroke.Y2
+		}
+	}
+
+	if down {
+		fmt.Fprintf(&buffer, "%s\n", g.PenUp)
+	}
+	buffer.WriteString("G0 X0 Y0\n")
+	buffer.WriteString("M2\n")
+
+	return buffer.WriteTo(w)
+}
+
+func (g *GCode) x(value int32) string {
+	return decimal(float64(value) * g.Scale)
+}
+
+func (g *GCode) y(value int32) string {
+	if g.FlipY {
+		value = Height - value
+	}
+	return decimal(float64(value) * g.Scale)
+}
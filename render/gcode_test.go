@@ -0,0 +1,62 @@
+package render
+
+import "testing"
+
+func TestGCode(t *testing.T) {
+	gcode := NewGCode()
+	runOn(t, gcode, pages)
+
+	// The pen moves up between strokes that do not join and before the
+	// pause for the next page
+	want := `; 160 x 120 mm
+G21 ; millimetres
+G90 ; absolute positions
+M5
+; ink red
+G0 X80 Y60
+M3
+G1 X82.5 Y60 F1500
+G1 X82.5 Y62.5
+; ink blue
+M5
+G0 X82.5 Y65
+M3
+G1 X82.5 Y67.5 F1500
+M5
+M0 ; next page
+; ink green
+G0 X80 Y60
+M3
+G1 X80 Y65 F1500
+M5
+G0 X0 Y0
+M2
+`
+	if got := write(t, gcode); got != want {
+		t.Errorf("wrote\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGCodeSettings(t *testing.T) {
+	gcode := NewGCode()
+	gcode.Scale, gcode.Feed = 0.5, 600
+	gcode.PenUp, gcode.PenDown = "G0 Z5", "G1 Z0"
+	gcode.FlipY = false
+	runOn(t, gcode, "pen down forward 10")
+
+	want := `; 320 x 240 mm
+G21 ; millimetres
+G90 ; absolute positions
+G0 Z5
+; ink white
+G0 X160 Y120
+G1 Z0
+G1 X165 Y120 F600
+G0 Z5
+G0 X0 Y0
+M2
+`
+	if got := write(t, gcode); got != want {
+		t.Errorf("wrote\n%s\nwant\n%s", got, want)
+	}
+}
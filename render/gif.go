@@ -0,0 +1,88 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+
+	"rs.lab/go-logo/logo"
+)
+
+// GIF is a drawing stub that records the drawing as an animated GIF. A frame
+// with the turtle is taken after every few lines and before the screen is
+// cleared, Finish adds the last frame that is shown longer.
+type GIF struct {
+	Image
+	Every  int     // lines drawn between two frames
+	Delay  int     // the time a frame is shown, in 100ths of a second
+	Hold   int     // the time the last frame is shown
+	Turtle float64 // the size of the turtle, no turtle if zero
+	lines  int     // lines drawn since the last frame
+	frames gif.GIF
+}
+
+func NewGIF() *GIF {
+	return &GIF{Image: *NewImage(), Every: 10, Delay: 2, Hold: 300, Turtle: 10}
+}
+
+func (g *GIF) Clear(r *logo.Runtime) {
+	if g.lines > 0 {
+		g.frame(r, g.Delay)
+	}
+	g.Image.Clear(r)
+}
+
+func (g *GIF) DrawLine(r *logo.Runtime, x1, y1, x2, y2 int32) {
+	g.Image.DrawLine(r, x1, y1, x2, y2)
+	g.lines += 1
+	if g.lines >= g.Every {
+		g.frame(r, g.Delay)
+	}
+}
+
+// Finish takes the last frame, after the program has run
+func (g *GIF) Finish(r *logo.Runtime) {
+	g.frame(r, g.Hold)
+}
+
+// frame takes the drawing and the turtle on top
+func (g *GIF) frame(r *logo.Runtime, delay int) {
+	frame := image.NewPaletted(g.RGBA.Bounds(), palette())
+	draw.Draw(frame, frame.Bounds(), g.RGBA, image.Point{}, draw.Src)
+	if g.Turtle > 0 {
+		DrawTurtle(frame, r, g.Turtle)
+	}
+
+	g.frames.Image = append(g.frames.Image, frame)
+	g.frames.Delay = append(g.frames.Delay, delay)
+	g.lines = 0
+}
+
+func (g *GIF) WriteTo(w io.Writer) (int64, error) {
+	counter := &countingWriter{w: w}
+	err := gif.EncodeAll(counter, &g.frames)
+	return counter.n, err
+}
+
+// palette returns the colors of the palette in the order of the inks
+func palette() color.Palette {
+	palette := make(color.Palette, 0, len(Palette))
+	for c := logo.Color(0); int(c) < len(Palette); c++ {
+		palette = append(palette, Palette[c])
+	}
+	return palette
+}
+
+// countingWriter counts the bytes written, for the encoders that do not
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
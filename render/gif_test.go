@@ -0,0 +1,72 @@
+package render
+
+import (
+	"bytes"
+	"image/gif"
+	"io"
+	"slices"
+	"testing"
+
+	"rs.lab/go-logo/logo"
+)
+
+func TestGIFFrames(t *testing.T) {
+	animation := NewGIF()
+	animation.Every, animation.Delay, animation.Hold = 2, 5, 100
+
+	r := logo.NewRuntime()
+	r.Writer = io.Discard
+	r.Stub = animation
+	// A frame after every two lines and one before HOME clears the screen,
+	// Finish holds the last one
+	program := "pen down repeat 5 forward 10 right 72 loop home pen down forward 10"
+	if err := r.Run(program); err != nil {
+		t.Fatal(err)
+	}
+	animation.Finish(r)
+
+	decoded, err := gif.DecodeAll(bytes.NewReader([]byte(write(t, animation))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{5, 5, 5, 100}; !slices.Equal(decoded.Delay, want) {
+		t.Errorf("the delays of the frames are %v, want %v", decoded.Delay, want)
+	}
+	for i, frame := range decoded.Image {
+		if bounds := frame.Bounds(); bounds.Dx() != Width || bounds.Dy() != Height {
+			t.Errorf("frame %d is %v", i, bounds)
+		}
+	}
+}
+
+func TestGIFTurtle(t *testing.T) {
+	frames := func(turtle float64) *gif.GIF {
+		animation := NewGIF()
+		animation.Turtle = turtle
+		r := logo.NewRuntime()
+		r.Stub = animation
+		animation.Finish(r)
+
+		decoded, err := gif.DecodeAll(bytes.NewReader([]byte(write(t, animation))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return decoded
+	}
+
+	// Without a turtle the only frame is the black paper
+	plain := frames(0)
+	if len(plain.Image) != 1 || plain.Delay[0] != 300 {
+		t.Fatalf("%d frames, delays %v", len(plain.Image), plain.Delay)
+	}
+	for _, index := range plain.Image[0].Pix {
+		if index != uint8(logo.Black) {
+			t.Fatalf("a pixel of the empty paper is %d", index)
+		}
+	}
+
+	turtle := frames(10)
+	if slices.Equal(turtle.Image[0].Pix, plain.Image[0].Pix) {
+		t.Error("the turtle is not drawn")
+	}
+}
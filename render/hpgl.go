@@ -0,0 +1,95 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"rs.lab/go-logo/logo"
+)
+
+// HPGL is a drawing stub that writes the strokes as HPGL for plotters and
+// cutting machines. The inks select the pens, the pages are advanced with PG.
+type HPGL struct {
+	Recorder
+	Scale float64            // millimetres for a unit of the canvas
+	Pens  map[logo.Color]int // the pen of every ink, an ink without a pen uses pen 1
+	FlipY bool               // the Y axis of the plotter grows upward
+}
+
+// DefaultPens numbers the pens in the order of the colors, black is pen 1
+func DefaultPens() map[logo.Color]int {
+	pens := map[logo.Color]int{}
+	for _, c := range logo.COLORS {
+		pens[c] = int(c) + 1
+	}
+	return pens
+}
+
+// ParsePens reads a pen table like red=2,blue=3 over the default pens
+func ParsePens(table string) (map[logo.Color]int, error) {
+	pens := DefaultPens()
+	if table == "" {
+		return pens, nil
+	}
+
+	for _, entry := range strings.Split(table, ",") {
+		name, number, _ := strings.Cut(entry, "=")
+		c, ok := logo.COLORS[strings.ToUpper(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown color %s in the pen table", name)
+		}
+		pen, err := strconv.Atoi(strings.TrimSpace(number))
+		if err != nil || pen < 0 {
+			return nil, fmt.Errorf("invalid pen %s for %s in the pen table", number, name)
+		}
+		pens[c] = pen
+	}
+	return pens, nil
+}
+
+func NewHPGL() *HPGL {
+	return &HPGL{Scale: 0.25, Pens: DefaultPens(), FlipY: true}
+}
+
+func (h *HPGL) WriteTo(w io.Writer) (int64, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString("IN;\n")
+
+	pen := -1
+	for i, page := range h.Pages {
+		if i > 0 {
+			buffer.WriteString("PG;\n")
+		}
+
+		for j, stroke := range page.Strokes {
+			number, ok := h.Pens[stroke.Ink]
+			if !ok {
+				number = 1
+			}
+			if number != pen {
+				fmt.Fprintf(&buffer, "SP%d;\n", number)
+				pen = number
+			}
+
+			if j == 0 || page.Strokes[j-1].Ink != stroke.Ink || page.Strokes[j-1].X2 != stroke.X1 || page.Strokes[j-1].Y2 != stroke.Y1 {
+				fmt.Fprintf(&buffer, "PU%s;\n", h.point(stroke.X1, stroke.Y1))
+			}
+			fmt.Fprintf(&buffer, "PD%s;\n", h.point(stroke.X2, stroke.Y2))
+		}
+	}
+
+	buffer.WriteString("PU;\nSP0;\n")
+	return buffer.WriteTo(w)
+}
+
+// point converts to plotter units, 40 to a millimetre
+func (h *HPGL) point(x, y int32) string {
+	if h.FlipY {
+		y = Height - y
+	}
+	return fmt.Sprintf("%d,%d", int(math.Round(float64(x)*h.Scale*40)), int(math.Round(float64(y)*h.Scale*40)))
+}
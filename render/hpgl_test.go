@@ -0,0 +1,76 @@
+package render
+
+import (
+	"testing"
+
+	"rs.lab/go-logo/logo"
+)
+
+func TestHPGL(t *testing.T) {
+	hpgl := NewHPGL()
+	runOn(t, hpgl, pages)
+
+	// The pen goes up between strokes that do not join, PG advances the
+	// page and every ink selects its pen
+	want := `IN;
+SP3;
+PU3200,2400;
+PD3300,2400;
+PD3300,2500;
+SP5;
+PU3300,2600;
+PD3300,2700;
+PG;
+SP4;
+PU3200,2400;
+PD3200,2600;
+PU;
+SP0;
+`
+	if got := write(t, hpgl); got != want {
+		t.Errorf("wrote\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHPGLPens(t *testing.T) {
+	hpgl := NewHPGL()
+	pens, err := ParsePens("red=2, blue = 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hpgl.Pens = pens
+	delete(hpgl.Pens, logo.Green)
+	runOn(t, hpgl, pages)
+
+	// Red and blue share a pen, green has none and uses pen 1
+	want := `IN;
+SP2;
+PU3200,2400;
+PD3300,2400;
+PD3300,2500;
+PU3300,2600;
+PD3300,2700;
+PG;
+SP1;
+PU3200,2400;
+PD3200,2600;
+PU;
+SP0;
+`
+	if got := write(t, hpgl); got != want {
+		t.Errorf("wrote\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestParsePens(t *testing.T) {
+	pens, err := ParsePens("")
+	if err != nil || pens[logo.Black] != 1 || pens[logo.White] != 2 || pens[logo.Magenta] != 8 {
+		t.Errorf("default pens %v, %v", pens, err)
+	}
+
+	for _, table := range []string{"purple=2", "red=x", "red=-1", "red"} {
+		if _, err := ParsePens(table); err == nil {
+			t.Errorf("%s is a pen table", table)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"rs.lab/go-logo/logo"
+)
+
+// Image is a drawing stub that draws onto an RGBA image, it needs no window
+type Image struct {
+	logo.DrawingStub
+	RGBA *image.RGBA
+}
+
+func NewImage() *Image {
+	rgba := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	draw.Draw(rgba, rgba.Bounds(), image.NewUniform(Palette[logo.Black]), image.Point{}, draw.Src)
+	return &Image{RGBA: rgba}
+}
+
+func (i *Image) Clear(r *logo.Runtime) {
+	draw.Draw(i.RGBA, i.RGBA.Bounds(), image.NewUniform(Palette[r.Paper]), image.Point{}, draw.Src)
+}
+
+func (i *Image) DrawLine(r *logo.Runtime, x1, y1, x2, y2 int32) {
+	drawLine(i.RGBA, int(x1), int(y1), int(x2), int(y2), Palette[r.Ink])
+}
+
+// DrawTurtle draws the turtle onto the image, the same triangle the
+// visualizer draws
+func DrawTurtle(img draw.Image, r *logo.Runtime, size float64) {
+	t := r.DegToRad(r.Angle)
+	ax, ay := r.Head.X+size*math.Cos(t), r.Head.Y+size*math.Sin(t)
+	px, py := r.Head.X+size/8*math.Cos(t), r.Head.Y+size/8*math.Sin(t)
+	bx, by := r.Head.X+size*math.Cos(t+2*math.Pi/3), r.Head.Y+size*math.Sin(t+2*math.Pi/3)
+	cx, cy := r.Head.X+size*math.Cos(t-2*math.Pi/3), r.Head.Y+size*math.Sin(t-2*math.Pi/3)
+
+	red := Palette[logo.Red]
+	drawLine(img, int(ax), int(ay), int(bx), int(by), red)
+	drawLine(img, int(ax), int(ay), int(cx), int(cy), red)
+	drawLine(img, int(bx), int(by), int(cx), int(cy), red)
+	drawLine(img, int(ax), int(ay), int(px), int(py), red)
+}
+
+// drawLine draws a one pixel wide line, the pixels outside of the image are
+// left out
+func drawLine(img draw.Image, x1, y1, x2, y2 int, c color.Color) {
+	bounds := img.Bounds()
+	line(x1, y1, x2, y2, func(x, y int) {
+		if (image.Point{x, y}).In(bounds) {
+			img.Set(x, y, c)
+		}
+	})
+}
+
+// line plots the points of a line with the Bresenham algorithm
+func line(x1, y1, x2, y2 int, plot func(x, y int)) {
+	dx, dy := abs(x2-x1), -abs(y2-y1)
+	sx, sy := 1, 1
+	if x1 > x2 {
+		sx = -1
+	}
+	if y1 > y2 {
+		sy = -1
+	}
+
+	for e := dx + dy; ; {
+		plot(x1, y1)
+		if x1 == x2 && y1 == y2 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x1 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y1 += sy
+		}
+	}
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
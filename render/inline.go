@@ -0,0 +1,139 @@
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image/png"
+	"io"
+	"math"
+)
+
+// The terminal graphics protocols show the drawing inline. Both encoders
+// write the same bytes for the same drawing, so their output can be compared
+// to a file known to be right.
+
+// Sixel is a drawing stub that writes the drawing as DEC sixel graphics
+type Sixel struct {
+	Image
+}
+
+func NewSixel() *Sixel {
+	return &Sixel{Image: *NewImage()}
+}
+
+func (s *Sixel) WriteTo(w io.Writer) (int64, error) {
+	counter := &countingWriter{w: w}
+	out := bufio.NewWriter(counter)
+	bounds := s.RGBA.Bounds()
+
+	// The color registers are the inks, in percent
+	fmt.Fprintf(out, "\x1bPq\"1;1;%d;%d", bounds.Dx(), bounds.Dy())
+	colors := palette()
+	for i, c := range colors {
+		r, g, b, _ := c.RGBA()
+		fmt.Fprintf(out, "#%d;2;%d;%d;%d", i, percent(r), percent(g), percent(b))
+	}
+
+	pixels := make([]int, bounds.Dx()*bounds.Dy())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			pixels[(y-bounds.Min.Y)*bounds.Dx()+x-bounds.Min.X] = colors.Index(s.RGBA.At(x, y))
+		}
+	}
+
+	// Every band of six rows is drawn color by color, $ goes back to the
+	// start of the band and - to the next band
+	for top := 0; top < bounds.Dy(); top += 6 {
+		bands := make([][]byte, len(colors))
+		for x := 0; x < bounds.Dx(); x++ {
+			for row := 0; row < 6 && top+row < bounds.Dy(); row++ {
+				index := pixels[(top+row)*bounds.Dx()+x]
+				if bands[index] == nil {
+					bands[index] = make([]byte, bounds.Dx())
+				}
+				bands[index][x] |= 1 << row
+			}
+		}
+
+		first := true
+		for index, band := range bands {
+			if band == nil {
+				continue
+			}
+			if !first {
+				out.WriteString("$")
+			}
+			first = false
+			fmt.Fprintf(out, "#%d", index)
+			writeSixels(out, band)
+		}
+		out.WriteString("-")
+	}
+
+	out.WriteString("\x1b\\\n")
+	err := out.Flush()
+	return counter.n, err
+}
+
+// writeSixels writes the columns of a band, repeats are run length encoded
+func writeSixels(out *bufio.Writer, band []byte) {
+	for x := 0; x < len(band); {
+		count := 1
+		for x+count < len(band) && band[x+count] == band[x] {
+			count += 1
+		}
+
+		char := band[x] + 63
+		if count > 3 {
+			fmt.Fprintf(out, "!%d%c", count, char)
+		} else {
+			out.Write(bytes.Repeat([]byte{char}, count))
+		}
+		x += count
+	}
+}
+
+func percent(value uint32) int {
+	return int(math.Round(float64(value) * 100 / 0xffff))
+}
+
+// Kitty is a drawing stub that writes the drawing with the graphics protocol
+// of the kitty terminal, as a PNG sent in chunks
+type Kitty struct {
+	Image
+}
+
+func NewKitty() *Kitty {
+	return &Kitty{Image: *NewImage()}
+}
+
+func (k *Kitty) WriteTo(w io.Writer) (int64, error) {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, k.RGBA); err != nil {
+		return 0, err
+	}
+	data := base64.StdEncoding.EncodeToString(encoded.Bytes())
+
+	counter := &countingWriter{w: w}
+	out := bufio.NewWriter(counter)
+	const chunk = 4096
+	for start := 0; start < len(data); start += chunk {
+		end := min(start+chunk, len(data))
+		more := 0
+		if end < len(data) {
+			more = 1
+		}
+
+		if start == 0 {
+			fmt.Fprintf(out, "\x1b_Ga=T,f=100,m=%d;%s\x1b\\", more, data[start:end])
+		} else {
+			fmt.Fprintf(out, "\x1b_Gm=%d;%s\x1b\\", more, data[start:end])
+		}
+	}
+	out.WriteString("\n")
+
+	err := out.Flush()
+	return counter.n, err
+}
@@ -0,0 +1,73 @@
+package render
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rs.lab/go-logo/logo"
+)
+
+var update = flag.Bool("update", false, "write the golden files of the terminal graphics")
+
+// The drawing of the golden files: a few inks on a paper, with the pen up
+// between the shapes
+const drawing = `
+paper blue
+ink yellow
+home
+pen down
+repeat 4 forward 100 right 90 loop
+pen up
+left 135 forward 150
+pen down
+ink red
+repeat 5 forward 120 right 144 loop
+ink white
+repeat 36 forward 10 right 10 loop
+`
+
+func TestInlineGolden(t *testing.T) {
+	stubs := map[string]func() (logo.DrawingStub, io.WriterTo){
+		"sixel": func() (logo.DrawingStub, io.WriterTo) { s := NewSixel(); return s, s },
+		"kitty": func() (logo.DrawingStub, io.WriterTo) { k := NewKitty(); return k, k },
+	}
+
+	for name, stub := range stubs {
+		t.Run(name, func(t *testing.T) {
+			r := logo.NewRuntime()
+			r.Writer = io.Discard
+			var document io.WriterTo
+			r.Stub, document = stub()
+			if err := r.Run(drawing); err != nil {
+				t.Fatal(err)
+			}
+
+			var out bytes.Buffer
+			n, err := document.WriteTo(&out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != int64(out.Len()) {
+				t.Errorf("WriteTo counted %d bytes, wrote %d", n, out.Len())
+			}
+
+			golden := filepath.Join("testdata", "drawing."+name)
+			if *update {
+				if err := os.WriteFile(golden, out.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(out.Bytes(), want) {
+				t.Fatalf("the output differs from %s, go test -update writes it again", golden)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package render
+
+import (
+	"slices"
+
+	"rs.lab/go-logo/logo"
+)
+
+// Optimize prepares the pages for a plotter. On every page the strokes drawn
+// twice and the dots on other strokes are dropped, the strokes meeting at
+// their ends are joined to polylines and the polylines are ordered and turned
+// around so the pen travels as little as it can with the pen up. Straight
+// runs of strokes become single strokes. The strokes are grouped by ink in
+// the order the inks were first used in, so the pens change as often as in
+// the drawing or less, but an ink no longer covers the strokes of the others
+// drawn after it. That is fine for a plotter and wrong for an image.
+func Optimize(pages []Page) []Page {
+	optimized := make([]Page, len(pages))
+	for i, page := range pages {
+		optimized[i] = Page{Paper: page.Paper, Strokes: []Stroke{}}
+		if len(page.Strokes) == 0 {
+			continue
+		}
+
+		position := Point{page.Strokes[0].X1, page.Strokes[0].Y1}
+		for _, ink := range inks(page.Strokes) {
+			var polylines []Polyline
+			polylines, position = chain(segments(page.Strokes, ink), ink, position)
+			for _, polyline := range polylines {
+				optimized[i].Strokes = append(optimized[i].Strokes, straighten(polyline).strokes()...)
+			}
+		}
+	}
+	return optimized
+}
+
+// segment is a stroke without its ink
+type segment struct {
+	a, b Point
+}
+
+// inks returns the inks of the strokes in the order they are first used
+func inks(strokes []Stroke) []logo.Color {
+	inks := []logo.Color{}
+	for _, stroke := range strokes {
+		if !slices.Contains(inks, stroke.Ink) {
+			inks = append(inks, stroke.Ink)
+		}
+	}
+	return inks
+}
+
+// segments returns the strokes of the ink, each only once whichever way it
+// was drawn and without the dots at their ends
+func segments(strokes []Stroke, ink logo.Color) []segment {
+	seen := map[segment]bool{}
+	segments := []segment{}
+	for _, stroke := range strokes {
+		if stroke.Ink != ink {
+			continue
+		}
+
+		s := segment{Point{stroke.X1, stroke.Y1}, Point{stroke.X2, stroke.Y2}}
+		if seen[s] || seen[segment{s.b, s.a}] {
+			continue
+		}
+		seen[s] = true
+		segments = append(segments, s)
+	}
+
+	// A dot on a line adds nothing to the drawing
+	ends := map[Point]bool{}
+	for _, s := range segments {
+		if s.a != s.b {
+			ends[s.a], ends[s.b] = true, true
+		}
+	}
+	return slices.DeleteFunc(segments, func(s segment) bool {
+		return s.a == s.b && ends[s.a]
+	})
+}
+
+// chain joins the segments meeting at their ends to polylines, as long as
+// they can be, then orders them
+func chain(segments []segment, ink logo.Color, position Point) ([]Polyline, Point) {
+	// The segments by their end points, to find the next one quickly
+	ends := map[Point][]int{}
+	for i, s := range segments {
+		ends[s.a] = append(ends[s.a], i)
+		ends[s.b] = append(ends[s.b], i)
+	}
+
+	used := make([]bool, len(segments))
+	polylines := []Polyline{}
+	for i, s := range segments {
+		if used[i] {
+			continue
+		}
+
+		used[i] = true
+		points := extend(segments, ends, used, []Point{s.a, s.b}, i, 1)
+		slices.Reverse(points)
+		points = extend(segments, ends, used, points, i, -1)
+		polylines = append(polylines, Polyline{Ink: ink, Points: points})
+	}
+
+	return order(polylines, position)
+}
+
+// extend adds the free segments going on from the end of the points, last is
+// the segment added last and step the way through the drawing order
+func extend(segments []segment, ends map[Point][]int, used []bool, points []Point, last, step int) []Point {
+	for {
+		end := points[len(points)-1]
+		next := follow(segments, ends[end], used, points, last+step)
+		if next == -1 {
+			return points
+		}
+
+		used[next] = true
+		last = next
+		other := segments[next].b
+		if other == end {
+			other = segments[next].a
+		}
+		points = append(points, other)
+	}
+}
+
+// order puts the polyline closest to the pen first, turned around if its end
+// is closer, then the one closest to its end and so on. It returns where the
+// pen stops.
+func order(polylines []Polyline, position Point) ([]Polyline, Point) {
+	ordered := make([]Polyline, 0, len(polylines))
+	used := make([]bool, len(polylines))
+	for range polylines {
+		best, reversed, distance := -1, false, int64(0)
+		for i, polyline := range polylines {
+			if used[i] {
+				continue
+			}
+			if d := distance2(position, polyline.Points[0]); best == -1 || d < distance {
+				best, reversed, distance = i, false, d
+			}
+			if d := distance2(position, polyline.Points[len(polyline.Points)-1]); d < distance {
+				best, reversed, distance = i, true, d
+			}
+		}
+
+		used[best] = true
+		polyline := polylines[best]
+		if reversed {
+			polyline.Points = slices.Clone(polyline.Points)
+			slices.Reverse(polyline.Points)
+		}
+		ordered = append(ordered, polyline)
+		position = polyline.Points[len(polyline.Points)-1]
+	}
+	return ordered, position
+}
+
+// follow picks the free segment that goes on from the end of the points.
+// The one drawn next goes first, so the pen keeps the way of the drawing,
+// then the one going straight on.
+func follow(segments []segment, candidates []int, used []bool, points []Point, drawn int) int {
+	end := points[len(points)-1]
+	next := -1
+	for _, i := range candidates {
+		if used[i] {
+			continue
+		}
+		if i == drawn {
+			return i
+		}
+
+		other := segments[i].b
+		if other == end {
+			other = segments[i].a
+		}
+		if len(points) > 1 && straight(points[len(points)-2], end, other) {
+			return i
+		}
+		if next == -1 {
+			next = i
+		}
+	}
+	return next
+}
+
+// straighten drops the points in the middle of straight runs
+func straighten(polyline Polyline) Polyline {
+	points := []Point{polyline.Points[0]}
+	for i := 1; i < len(polyline.Points); i++ {
+		point := polyline.Points[i]
+		if n := len(points); n > 1 && straight(points[n-2], points[n-1], point) {
+			points[n-1] = point
+			continue
+		}
+		points = append(points, point)
+	}
+	return Polyline{Ink: polyline.Ink, Points: points}
+}
+
+// straight checks whether b is on the way from a to c
+func straight(a, b, c Point) bool {
+	abx, aby := int64(b.X-a.X), int64(b.Y-a.Y)
+	bcx, bcy := int64(c.X-b.X), int64(c.Y-b.Y)
+	return abx*bcy-aby*bcx == 0 && abx*bcx+aby*bcy > 0
+}
+
+// strokes turns the polyline back to strokes
+func (p Polyline) strokes() []Stroke {
+	strokes := make([]Stroke, 0, len(p.Points)-1)
+	for i := 1; i < len(p.Points); i++ {
+		strokes = append(strokes, Stroke{X1: p.Points[i-1].X, Y1: p.Points[i-1].Y, X2: p.Points[i].X, Y2: p.Points[i].Y, Ink: p.Ink})
+	}
+	return strokes
+}
+
+func distance2(a, b Point) int64 {
+	dx, dy := int64(a.X-b.X), int64(a.Y-b.Y)
+	return dx*dx + dy*dy
+}
@@ -0,0 +1,103 @@
+package render
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"rs.lab/go-logo/logo"
+)
+
+// stroke is a white stroke from x1,y1 to x2,y2
+func stroke(x1, y1, x2, y2 int32) Stroke {
+	return Stroke{X1: x1, Y1: y1, X2: x2, Y2: y2, Ink: logo.White}
+}
+
+// inked gives the stroke another ink
+func inked(stroke Stroke, ink logo.Color) Stroke {
+	stroke.Ink = ink
+	return stroke
+}
+
+func formatStrokes(strokes []Stroke) string {
+	lines := make([]string, len(strokes))
+	for i, s := range strokes {
+		lines[i] = fmt.Sprintf("%s %d,%d-%d,%d", colorName(s.Ink), s.X1, s.Y1, s.X2, s.Y2)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		name      string
+		strokes   []Stroke
+		optimized []Stroke
+	}{
+		{
+			"drawn twice",
+			[]Stroke{stroke(0, 0, 10, 5), stroke(10, 5, 0, 0), stroke(0, 0, 10, 5)},
+			[]Stroke{stroke(0, 0, 10, 5)},
+		},
+		{
+			"joined at the ends",
+			[]Stroke{stroke(0, 0, 10, 0), stroke(10, 10, 10, 0), stroke(10, 10, 0, 10)},
+			[]Stroke{stroke(0, 0, 10, 0), stroke(10, 0, 10, 10), stroke(10, 10, 0, 10)},
+		},
+		{
+			"straight runs",
+			[]Stroke{stroke(0, 0, 10, 0), stroke(10, 0, 20, 0), stroke(20, 0, 30, 0), stroke(30, 0, 30, 10)},
+			[]Stroke{stroke(0, 0, 30, 0), stroke(30, 0, 30, 10)},
+		},
+		{
+			"going back is not straight",
+			[]Stroke{stroke(0, 0, 10, 0), stroke(10, 0, 5, 0)},
+			[]Stroke{stroke(0, 0, 10, 0), stroke(10, 0, 5, 0)},
+		},
+		{
+			"dots",
+			[]Stroke{stroke(0, 0, 10, 0), stroke(10, 0, 10, 0), stroke(5, 0, 5, 0), stroke(50, 50, 50, 50)},
+			[]Stroke{stroke(0, 0, 10, 0), stroke(5, 0, 5, 0), stroke(50, 50, 50, 50)},
+		},
+		{
+			"inks in the order of first use",
+			[]Stroke{inked(stroke(0, 0, 10, 0), logo.Red), inked(stroke(10, 0, 20, 10), logo.Blue), inked(stroke(20, 10, 30, 10), logo.Red)},
+			// the blue stroke starts at the end closer to where red stopped
+			[]Stroke{inked(stroke(0, 0, 10, 0), logo.Red), inked(stroke(20, 10, 30, 10), logo.Red), inked(stroke(20, 10, 10, 0), logo.Blue)},
+		},
+		{
+			"nearest first, turned around",
+			[]Stroke{stroke(0, 0, 10, 0), stroke(100, 10, 50, 10), stroke(20, 10, 40, 10)},
+			[]Stroke{stroke(0, 0, 10, 0), stroke(20, 10, 40, 10), stroke(50, 10, 100, 10)},
+		},
+		{
+			// the stroke drawn next goes on at a fork, the other branch is
+			// turned around to start closer
+			"the drawing order at a fork",
+			[]Stroke{stroke(0, 0, 10, 0), stroke(10, 0, 10, 10), stroke(10, -10, 10, 0)},
+			[]Stroke{stroke(0, 0, 10, 0), stroke(10, 0, 10, 10), stroke(10, 0, 10, -10)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pages := Optimize([]Page{{Paper: logo.Blue, Strokes: test.strokes}})
+			if len(pages) != 1 || pages[0].Paper != logo.Blue {
+				t.Fatalf("pages %v", pages)
+			}
+			if !slices.Equal(pages[0].Strokes, test.optimized) {
+				t.Errorf("optimized to\n%s\nwant\n%s", formatStrokes(pages[0].Strokes), formatStrokes(test.optimized))
+			}
+		})
+	}
+}
+
+func TestOptimizePages(t *testing.T) {
+	pages := Optimize([]Page{
+		{Paper: logo.Black, Strokes: []Stroke{}},
+		{Paper: logo.White, Strokes: []Stroke{stroke(0, 0, 10, 0), stroke(0, 0, 10, 0)}},
+	})
+	if len(pages) != 2 || len(pages[0].Strokes) != 0 || pages[1].Paper != logo.White || len(pages[1].Strokes) != 1 {
+		t.Errorf("pages %v", pages)
+	}
+}
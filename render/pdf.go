@@ -0,0 +1,147 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// PAGES are the known page sizes in points, landscape like the canvas
+var PAGES = map[string][2]float64{
+	"A3":     {1191, 842},
+	"A4":     {842, 595},
+	"A5":     {595, 420},
+	"LETTER": {792, 612},
+	"LEGAL":  {1008, 612},
+}
+
+// PageSize reads a page size, a name from PAGES or <width>x<height> in
+// millimetres, and returns it in points
+func PageSize(name string) (width, height float64, err error) {
+	if size, ok := PAGES[strings.ToUpper(name)]; ok {
+		return size[0], size[1], nil
+	}
+
+	w, h, ok := strings.Cut(strings.ToLower(name), "x")
+	if ok {
+		width, err = strconv.ParseFloat(w, 64)
+		if err == nil {
+			height, err = strconv.ParseFloat(h, 64)
+		}
+		if err == nil && width > 0 && height > 0 {
+			return width * 72 / 25.4, height * 72 / 25.4, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("unknown page size %s", name)
+}
+
+// PDF is a drawing stub that writes the pages as a vector PDF document. The
+// paper fills every page, the canvas is scaled to fit inside the margin.
+type PDF struct {
+	Recorder
+	Width, Height float64 // the page size in points
+	Margin        float64 // in points
+}
+
+func NewPDF() *PDF {
+	return &PDF{Width: PAGES["A4"][0], Height: PAGES["A4"][1], Margin: 36}
+}
+
+// WriteTo writes the document, a program that draws nothing gets one empty
+// page
+func (p *PDF) WriteTo(w io.Writer) (int64, error) {
+	pages := p.Pages
+	if len(pages) == 0 {
+		pages = []Page{{}}
+	}
+
+	doc := &pdfDocument{}
+	doc.object("<< /Type /Catalog /Pages 2 0 R >>")
+
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 3+2*i)
+	}
+	doc.object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+
+	for i, page := range pages {
+		content := p.content(page)
+		doc.object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Contents %d 0 R >>", decimal(p.Width), decimal(p.Height), 4+2*i))
+		doc.object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	return doc.WriteTo(w)
+}
+
+// content draws the page, joined strokes of the same ink make one path
+func (p *PDF) content(page Page) string {
+	scale := min((p.Width-2*p.Margin)/Width, (p.Height-2*p.Margin)/Height)
+	left := (p.Width - scale*Width) / 2
+	bottom := (p.Height - scale*Height) / 2
+	x := func(value int32) string { return decimal(left + scale*float64(value)) }
+	y := func(value int32) string { return decimal(bottom + scale*float64(Height-value)) }
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s rg 0 0 %s %s re f\n", pdfColor(Palette[page.Paper]), decimal(p.Width), decimal(p.Height))
+	fmt.Fprintf(&sb, "1 J 1 j %s w\n", decimal(scale))
+
+	for i, stroke := range page.Strokes {
+		if i == 0 || stroke.Ink != page.Strokes[i-1].Ink {
+			if i > 0 {
+				sb.WriteString("S\n")
+			}
+			fmt.Fprintf(&sb, "%s RG\n", pdfColor(Palette[stroke.Ink]))
+		}
+
+		previous := Stroke{X2: -1, Y2: -1}
+		if i > 0 {
+			previous = page.Strokes[i-1]
+		}
+		if previous.Ink != stroke.Ink || previous.X2 != stroke.X1 || previous.Y2 != stroke.Y1 {
+			fmt.Fprintf(&sb, "%s %s m ", x(stroke.X1), y(stroke.Y1))
+		}
+		fmt.Fprintf(&sb, "%s %s l\n", x(stroke.X2), y(stroke.Y2))
+	}
+
+	if len(page.Strokes) > 0 {
+		sb.WriteString("S\n")
+	}
+	return sb.String()
+}
+
+func pdfColor(c color.RGBA) string {
+	return fmt.Sprintf("%s %s %s", decimal(float64(c.R)/255), decimal(float64(c.G)/255), decimal(float64(c.B)/255))
+}
+
+// pdfDocument collects the objects of a document and writes them with the
+// cross-reference table
+type pdfDocument struct {
+	objects []string
+}
+
+func (doc *pdfDocument) object(body string) {
+	doc.objects = append(doc.objects, body)
+}
+
+func (doc *pdfDocument) WriteTo(w io.Writer) (int64, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(doc.objects))
+	for i, body := range doc.objects {
+		offsets[i] = buffer.Len()
+		fmt.Fprintf(&buffer, "%d 0 obj\n%s\nendobj\n", i+1, body)
+	}
+
+	xref := buffer.Len()
+	fmt.Fprintf(&buffer, "xref\n0 %d\n0000000000 65535 f \n", len(doc.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buffer, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buffer, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(doc.objects)+1, xref)
+
+	return buffer.WriteTo(w)
+}
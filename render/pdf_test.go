@@ -0,0 +1,122 @@
+package render
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"rs.lab/go-logo/logo"
+)
+
+// Two pages: a red corner and a blue stroke after the pen was up, HOME
+// starts the second page on white paper with a green stroke
+const pages = `
+pen down
+ink red
+forward 10 right 90 forward 10
+ink blue
+pen up forward 10 pen down
+forward 10
+paper white
+home
+ink green
+pen down
+forward 20
+`
+
+// runOn runs the program with the drawing stub
+func runOn(t *testing.T, stub logo.DrawingStub, program string) {
+	t.Helper()
+	r := logo.NewRuntime()
+	r.Writer = io.Discard
+	r.Stub = stub
+	if err := r.Run(program); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// write returns what the document writes, the count WriteTo returns has to
+// match it
+func write(t *testing.T, document io.WriterTo) string {
+	t.Helper()
+	var out bytes.Buffer
+	n, err := document.WriteTo(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(out.Len()) {
+		t.Errorf("WriteTo counted %d bytes, wrote %d", n, out.Len())
+	}
+	return out.String()
+}
+
+func TestPDFPages(t *testing.T) {
+	pdf := NewPDF()
+	runOn(t, pdf, pages)
+	document := write(t, pdf)
+
+	if !strings.HasPrefix(document, "%PDF-1.4\n") || !strings.HasSuffix(document, "%%EOF\n") {
+		t.Fatalf("not a PDF document:\n%s", document)
+	}
+	if !strings.Contains(document, "<< /Type /Pages /Kids [3 0 R 5 0 R] /Count 2 >>") {
+		t.Errorf("the document does not have 2 pages:\n%s", document)
+	}
+	if n := strings.Count(document, "/Type /Page "); n != 2 {
+		t.Errorf("%d page objects, want 2", n)
+	}
+
+	// The paper of the pages, then the strokes: the red ones join, the blue
+	// one starts again and the green one is on the second page
+	contents := regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`).FindAllStringSubmatch(document, -1)
+	if len(contents) != 2 {
+		t.Fatalf("%d page contents, want 2", len(contents))
+	}
+	first := "0 0 0 rg 0 0 842 595 re f\n1 J 1 j 1.09 w\n" +
+		"1 0 0 RG\n421 297.5 m 431.896 297.5 l\n431.896 308.396 l\n" +
+		"S\n0 0 1 RG\n431.896 319.292 m 431.896 330.188 l\nS\n"
+	if contents[0][1] != first {
+		t.Errorf("the first page draws\n%s\nwant\n%s", contents[0][1], first)
+	}
+	second := "1 1 1 rg 0 0 842 595 re f\n1 J 1 j 1.09 w\n0 1 0 RG\n421 297.5 m 421 319.292 l\nS\n"
+	if contents[1][1] != second {
+		t.Errorf("the second page draws\n%s\nwant\n%s", contents[1][1], second)
+	}
+
+	// The cross-reference table points at the objects
+	for _, match := range regexp.MustCompile(`(?m)^(\d{10}) 00000 n $`).FindAllStringSubmatch(document, -1) {
+		offset, _ := strconv.Atoi(match[1])
+		if !regexp.MustCompile(`^\d+ 0 obj\n`).MatchString(document[offset:]) {
+			t.Errorf("the offset %d is not an object", offset)
+		}
+	}
+}
+
+func TestPDFEmpty(t *testing.T) {
+	pdf := NewPDF()
+	runOn(t, pdf, "print 1")
+	if document := write(t, pdf); !strings.Contains(document, "/Count 1 >>") {
+		t.Errorf("a program drawing nothing does not get one page:\n%s", document)
+	}
+}
+
+func TestPageSize(t *testing.T) {
+	sizes := map[string][2]float64{
+		"a4":      {842, 595},
+		"Letter":  {792, 612},
+		"254x127": {720, 360},
+	}
+	for name, want := range sizes {
+		width, height, err := PageSize(name)
+		if err != nil || width != want[0] || height != want[1] {
+			t.Errorf("%s: %v x %v, %v", name, width, height, err)
+		}
+	}
+	for _, name := range []string{"B4", "100x", "0x100", "-10x10"} {
+		if _, _, err := PageSize(name); err == nil {
+			t.Errorf("%s is a page size", name)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package render
+
+import "rs.lab/go-logo/logo"
+
+// Point is a position on the canvas
+type Point struct {
+	X, Y int32
+}
+
+// Polyline is a run of strokes of the same ink, each starting where the
+// previous one ends
+type Polyline struct {
+	Ink    logo.Color
+	Points []Point
+}
+
+// Closed checks whether the polyline ends where it starts
+func (p Polyline) Closed() bool {
+	return len(p.Points) > 2 && p.Points[0] == p.Points[len(p.Points)-1]
+}
+
+// Polylines joins the strokes that follow each other to polylines, in the
+// order they were drawn
+func Polylines(strokes []Stroke) []Polyline {
+	polylines := []Polyline{}
+	for i, stroke := range strokes {
+		if i == 0 || strokes[i-1].Ink != stroke.Ink || strokes[i-1].X2 != stroke.X1 || strokes[i-1].Y2 != stroke.Y1 {
+			polylines = append(polylines, Polyline{Ink: stroke.Ink, Points: []Point{{stroke.X1, stroke.Y1}}})
+		}
+
+		last := &polylines[len(polylines)-1]
+		last.Points = append(last.Points, Point{stroke.X2, stroke.Y2})
+	}
+	return polylines
+}
@@ -0,0 +1,100 @@
+// Package render has the drawing stubs that turn the drawing of a program
+// into files: vector documents, plotter programs and images.
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"rs.lab/go-logo/logo"
+)
+
+// The logical canvas of the runtime, HOME puts the turtle in its center
+const (
+	Width  = 640
+	Height = 480
+)
+
+// Palette has the colors of the inks and the paper, the same as the
+// visualizer uses
+var Palette = map[logo.Color]color.RGBA{
+	logo.Black:   {0x00, 0x00, 0x00, 0xff},
+	logo.White:   {0xff, 0xff, 0xff, 0xff},
+	logo.Red:     {0xff, 0x00, 0x00, 0xff},
+	logo.Green:   {0x00, 0xff, 0x00, 0xff},
+	logo.Blue:    {0x00, 0x00, 0xff, 0xff},
+	logo.Yellow:  {0xff, 0xff, 0x00, 0xff},
+	logo.Gray:    {0x88, 0x88, 0x88, 0xff},
+	logo.Magenta: {0xff, 0x00, 0xff, 0xff},
+}
+
+// colorName returns the name of the color in lower case
+func colorName(c logo.Color) string {
+	for name, value := range logo.COLORS {
+		if value == c {
+			return strings.ToLower(name)
+		}
+	}
+	return fmt.Sprint(int(c))
+}
+
+// Stroke is a line drawn with the pen down
+type Stroke struct {
+	X1, Y1, X2, Y2 int32
+	Ink            logo.Color
+}
+
+// Page is the drawing between two clears of the screen
+type Page struct {
+	Paper   logo.Color
+	Strokes []Stroke
+}
+
+// Recorder is a drawing stub that keeps the strokes, a new page is started
+// by HOME once something is drawn on the current one
+type Recorder struct {
+	logo.DrawingStub
+	Pages []Page
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+func (rec *Recorder) Clear(r *logo.Runtime) {
+	if len(rec.Pages) == 0 || len(rec.Pages[len(rec.Pages)-1].Strokes) != 0 {
+		rec.Pages = append(rec.Pages, Page{})
+	}
+	rec.Pages[len(rec.Pages)-1].Paper = r.Paper
+}
+
+func (rec *Recorder) DrawLine(r *logo.Runtime, x1, y1, x2, y2 int32) {
+	if len(rec.Pages) == 0 {
+		rec.Pages = append(rec.Pages, Page{Paper: r.Paper})
+	}
+
+	page := &rec.Pages[len(rec.Pages)-1]
+	page.Strokes = append(page.Strokes, Stroke{X1: x1, Y1: y1, X2: x2, Y2: y2, Ink: r.Ink})
+}
+
+// Strokes returns the strokes of all pages
+func (rec *Recorder) Strokes() []Stroke {
+	strokes := []Stroke{}
+	for _, page := range rec.Pages {
+		strokes = append(strokes, page.Strokes...)
+	}
+	return strokes
+}
+
+// decimal writes the number with up to three decimals, the way the text
+// formats want them
+func decimal(value float64) string {
+	value = math.Round(value*1000) / 1000
+	if value == 0 {
+		value = 0 // no negative zero
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
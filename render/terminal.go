@@ -0,0 +1,169 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+
+	"rs.lab/go-logo/logo"
+)
+
+// ANSI are the 16 terminal colors closest to the inks and the paper, as
+// offsets to the SGR codes 30 (foreground) and 40 (background)
+var ANSI = map[logo.Color]int{
+	logo.Black:   0,
+	logo.White:   67, // bright white
+	logo.Red:     61, // bright red
+	logo.Green:   62,
+	logo.Blue:    64,
+	logo.Yellow:  63,
+	logo.Gray:    60, // bright black
+	logo.Magenta: 65,
+}
+
+// Terminal is a drawing stub that draws with characters, for terminals with
+// no graphics. A character holds 2x4 braille dots or two half blocks, one
+// above the other. The drawing is printed at the end with Flush and before
+// the screen is cleared, or after every line when animated.
+type Terminal struct {
+	logo.DrawingStub
+	Writer        io.Writer
+	Columns, Rows int           // the size of the drawing in characters
+	Braille       bool          // braille dots, half blocks otherwise
+	Color         bool          // ANSI colors for the inks and the paper
+	Animate       bool          // redraw in place after every line
+	Delay         time.Duration // the pause after a line when animated
+	paper         logo.Color
+	dots          []logo.Color // the ink of every dot, -1 for the paper
+	drawn         bool         // something is drawn since the last print
+	printed       bool         // printed in place once, move back up first
+}
+
+// NewTerminal makes a terminal drawing the width given in characters, the
+// height follows from the canvas
+func NewTerminal(writer io.Writer, columns int, braille bool) *Terminal {
+	t := &Terminal{Writer: writer, Columns: columns, Braille: braille, Color: true}
+	// Characters are about twice as high as wide, a braille character has
+	// 2x4 dots and a half block character 1x2
+	t.Rows = max(columns*Height/Width/2, 1)
+	t.reset(logo.Black)
+	return t
+}
+
+// size returns the number of dots across and down
+func (t *Terminal) size() (int, int) {
+	if t.Braille {
+		return t.Columns * 2, t.Rows * 4
+	}
+	return t.Columns, t.Rows * 2
+}
+
+func (t *Terminal) Clear(r *logo.Runtime) {
+	if t.drawn && !t.Animate {
+		t.Flush()
+	}
+
+	t.reset(r.Paper)
+	if t.Animate {
+		t.print()
+	}
+}
+
+// reset makes all dots paper
+func (t *Terminal) reset(paper logo.Color) {
+	w, h := t.size()
+	t.paper = paper
+	t.dots = make([]logo.Color, w*h)
+	for i := range t.dots {
+		t.dots[i] = -1
+	}
+	t.drawn = false
+}
+
+func (t *Terminal) DrawLine(r *logo.Runtime, x1, y1, x2, y2 int32) {
+	w, h := t.size()
+	sx := func(x int32) int { return int(x) * w / Width }
+	sy := func(y int32) int { return int(y) * h / Height }
+
+	line(sx(x1), sy(y1), sx(x2), sy(y2), func(x, y int) {
+		if x >= 0 && x < w && y >= 0 && y < h {
+			t.dots[y*w+x] = r.Ink
+		}
+	})
+	t.drawn = true
+
+	if t.Animate {
+		t.print()
+		time.Sleep(t.Delay)
+	}
+}
+
+// Flush prints the drawing, unless it is animated and so printed already
+func (t *Terminal) Flush() {
+	if !t.Animate {
+		t.print()
+	}
+	t.drawn = false
+}
+
+func (t *Terminal) print() {
+	out := bufio.NewWriter(t.Writer)
+	defer out.Flush()
+
+	if t.Animate && t.printed {
+		fmt.Fprintf(out, "\x1b[%dA\r", t.Rows)
+	}
+	t.printed = true
+
+	for row := 0; row < t.Rows; row++ {
+		colors := [2]logo.Color{-1, -1} // the colors set last in the row
+		for column := 0; column < t.Columns; column++ {
+			char, fg, bg := t.cell(column, row)
+			if t.Color && colors != [2]logo.Color{fg, bg} {
+				fmt.Fprintf(out, "\x1b[%d;%dm", 30+ANSI[fg], 40+ANSI[bg])
+				colors = [2]logo.Color{fg, bg}
+			}
+			out.WriteRune(char)
+		}
+		if t.Color {
+			out.WriteString("\x1b[0m")
+		}
+		out.WriteString("\n")
+	}
+}
+
+// cell returns the character of the cell with its colors
+func (t *Terminal) cell(column, row int) (rune, logo.Color, logo.Color) {
+	w, _ := t.size()
+	ink := func(x, y int) logo.Color { return t.dots[y*w+x] }
+
+	if !t.Braille {
+		upper, lower := ink(column, 2*row), ink(column, 2*row+1)
+		switch {
+		case upper == -1 && lower == -1:
+			return ' ', t.paper, t.paper
+		case lower == -1:
+			return '▀', upper, t.paper
+		case upper == -1:
+			return '▄', lower, t.paper
+		}
+		return '▀', upper, lower
+	}
+
+	// The bits of the braille dots, by their column and row in the cell
+	bits := [2][4]rune{{0x01, 0x02, 0x04, 0x40}, {0x08, 0x10, 0x20, 0x80}}
+	char, fg := rune(0x2800), t.paper
+	for x := 0; x < 2; x++ {
+		for y := 0; y < 4; y++ {
+			if c := ink(2*column+x, 4*row+y); c != -1 {
+				char |= bits[x][y]
+				fg = c
+			}
+		}
+	}
+	if char == 0x2800 {
+		char = ' '
+	}
+	return char, fg, t.paper
+}
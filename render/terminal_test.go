@@ -0,0 +1,92 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rs.lab/go-logo/logo"
+)
+
+func TestTerminalGolden(t *testing.T) {
+	terminals := map[string]func(out *bytes.Buffer) *Terminal{
+		"braille": func(out *bytes.Buffer) *Terminal { return NewTerminal(out, 40, true) },
+		"blocks":  func(out *bytes.Buffer) *Terminal { return NewTerminal(out, 40, false) },
+		"plain": func(out *bytes.Buffer) *Terminal {
+			terminal := NewTerminal(out, 40, true)
+			terminal.Color = false
+			return terminal
+		},
+	}
+
+	for name, terminal := range terminals {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			stub := terminal(&out)
+			runOn(t, stub, drawing)
+			stub.Flush()
+
+			golden := filepath.Join("testdata", "drawing."+name)
+			if *update {
+				if err := os.WriteFile(golden, out.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(out.Bytes(), want) {
+				t.Fatalf("the output differs from %s, go test -update writes it again", golden)
+			}
+		})
+	}
+}
+
+func TestTerminalColors(t *testing.T) {
+	var out bytes.Buffer
+	terminal := NewTerminal(&out, 4, false)
+	runOn(t, terminal, "paper blue home pen down ink yellow forward 200")
+	terminal.Flush()
+
+	// 4x1 characters: the line from the center goes through the lower half
+	// of the right two, the inks are the bright ANSI colors
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != terminal.Rows || terminal.Rows != 1 {
+		t.Fatalf("%d rows:\n%q", len(lines), out.String())
+	}
+	want := "\x1b[94;104m  \x1b[93;104m▄▄\x1b[0m"
+
+	for name, c := range logo.COLORS {
+		if _, ok := ANSI[c]; !ok {
+			t.Errorf("%s has no ANSI color", name)
+		}
+	}
+	if lines[0] != want {
+		t.Errorf("printed %q, want %q", lines[0], want)
+	}
+}
+
+func TestTerminalAnimation(t *testing.T) {
+	var out bytes.Buffer
+	terminal := NewTerminal(&out, 20, true)
+	terminal.Animate = true
+	runOn(t, terminal, "pen down repeat 3 forward 50 right 120 loop")
+	terminal.Flush()
+
+	// A frame after every line, each but the first goes back up by exactly
+	// the rows of a frame
+	up := fmt.Sprintf("\x1b[%dA\r", terminal.Rows)
+	frames := strings.Split(out.String(), up)
+	if len(frames) != 3 {
+		t.Fatalf("%d frames, want 3:\n%q", len(frames), out.String())
+	}
+	for i, frame := range frames {
+		if n := strings.Count(frame, "\n"); n != terminal.Rows {
+			t.Errorf("frame %d has %d lines, want %d", i, n, terminal.Rows)
+		}
+	}
+}
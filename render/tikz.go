@@ -0,0 +1,89 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"rs.lab/go-logo/logo"
+)
+
+// TikZ is a drawing stub that writes the strokes as TikZ pictures to include
+// in LaTeX documents, a picture for every page. Strokes that follow each
+// other make one \draw. The colors are defined from the palette first, the
+// document needs \usepackage{tikz}.
+type TikZ struct {
+	Recorder
+	Scale float64 // centimetres for a unit of the canvas
+	Paper bool    // fill the picture with the paper
+}
+
+func NewTikZ() *TikZ {
+	return &TikZ{Scale: 0.025, Paper: true}
+}
+
+func (t *TikZ) WriteTo(w io.Writer) (int64, error) {
+	pages := t.Pages
+	if len(pages) == 0 {
+		pages = []Page{{}}
+	}
+
+	colors := []logo.Color{}
+	use := func(c logo.Color) {
+		if !slices.Contains(colors, c) {
+			colors = append(colors, c)
+		}
+	}
+	for _, page := range pages {
+		if t.Paper {
+			use(page.Paper)
+		}
+		for _, stroke := range page.Strokes {
+			use(stroke.Ink)
+		}
+	}
+	slices.Sort(colors)
+
+	var buffer bytes.Buffer
+	for _, c := range colors {
+		rgb := Palette[c]
+		fmt.Fprintf(&buffer, "\\definecolor{%s}{RGB}{%d,%d,%d}\n", tikzColor(c), rgb.R, rgb.G, rgb.B)
+	}
+
+	for _, page := range pages {
+		buffer.WriteString("\n\\begin{tikzpicture}[line cap=round, line join=round]\n")
+		if t.Paper {
+			fmt.Fprintf(&buffer, "  \\fill[%s] (0,0) rectangle (%s,%s);\n", tikzColor(page.Paper), decimal(Width*t.Scale), decimal(Height*t.Scale))
+		}
+
+		for _, polyline := range Polylines(page.Strokes) {
+			points := polyline.Points
+			end := ""
+			if polyline.Closed() {
+				points, end = points[:len(points)-1], " -- cycle"
+			}
+
+			coordinates := make([]string, len(points))
+			for i, point := range points {
+				coordinates[i] = t.point(point)
+			}
+			fmt.Fprintf(&buffer, "  \\draw[%s] %s%s;\n", tikzColor(polyline.Ink), strings.Join(coordinates, " -- "), end)
+		}
+		buffer.WriteString("\\end{tikzpicture}\n")
+	}
+
+	return buffer.WriteTo(w)
+}
+
+// point writes the coordinates in centimetres, the Y axis of TikZ grows
+// upward
+func (t *TikZ) point(point Point) string {
+	return fmt.Sprintf("(%s,%s)", decimal(float64(point.X)*t.Scale), decimal(float64(Height-point.Y)*t.Scale))
+}
+
+// tikzColor names the color defined for the ink
+func tikzColor(c logo.Color) string {
+	return "logo" + colorName(c)
+}
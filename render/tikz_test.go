@@ -0,0 +1,47 @@
+package render
+
+import "testing"
+
+func TestTikZ(t *testing.T) {
+	tikz := NewTikZ()
+	runOn(t, tikz, pages)
+
+	// A picture for every page, the colors used are defined first
+	want := `\definecolor{logoblack}{RGB}{0,0,0}
+\definecolor{logowhite}{RGB}{255,255,255}
+\definecolor{logored}{RGB}{255,0,0}
+\definecolor{logogreen}{RGB}{0,255,0}
+\definecolor{logoblue}{RGB}{0,0,255}
+
+\begin{tikzpicture}[line cap=round, line join=round]
+  \fill[logoblack] (0,0) rectangle (16,12);
+  \draw[logored] (8,6) -- (8.25,6) -- (8.25,6.25);
+  \draw[logoblue] (8.25,6.5) -- (8.25,6.75);
+\end{tikzpicture}
+
+\begin{tikzpicture}[line cap=round, line join=round]
+  \fill[logowhite] (0,0) rectangle (16,12);
+  \draw[logogreen] (8,6) -- (8,6.5);
+\end{tikzpicture}
+`
+	if got := write(t, tikz); got != want {
+		t.Errorf("wrote\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTikZWithoutPaper(t *testing.T) {
+	tikz := NewTikZ()
+	tikz.Scale, tikz.Paper = 0.1, false
+	runOn(t, tikz, "pen down ink red repeat 4 forward 10 right 90 loop")
+
+	// The paper is neither defined nor filled, the closed square is a cycle
+	want := `\definecolor{logored}{RGB}{255,0,0}
+
+\begin{tikzpicture}[line cap=round, line join=round]
+  \draw[logored] (32,24) -- (33,24) -- (33,25) -- (32,25) -- cycle;
+\end{tikzpicture}
+`
+	if got := write(t, tikz); got != want {
+		t.Errorf("wrote\n%s\nwant\n%s", got, want)
+	}
+}